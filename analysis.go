@@ -0,0 +1,168 @@
+package technical_debt
+
+import (
+	"math"
+	"path"
+	"strings"
+)
+
+// Analysis is everything learned by running the algorithm over a dependency graph.
+type Analysis struct {
+	CodeFiles        map[string]CodeFile // Every node with its direct dependencies.
+	Condensation     Condensation        // The strongly connected components of the direct dependencies.
+	Closure          Closure             // The deep dependencies between components.
+	Production       *Closure            // The deep dependencies without tests, only for the test overlay.
+	PropogationCost  float64
+	DirectDensity    float64 // The fraction of the grid that is direct dependencies.
+	Prefix           string  // The longest prefix shared by node names.
+	Groups           []CyclicalGroup
+	CoreCount        int // The size of the largest cyclical group.
+	FileCount        int
+	VisibilityFanIn  int // The visibility fan in dividing the partitions.
+	VisibilityFanOut int // The visibility fan out dividing the partitions.
+	Partitions       []Partition
+	MaxReferences    int       // The most references along any direct dependency.
+	Distances        Distances // How far apart dependencies are, if measured.
+}
+
+// Analyze runs the algorithm over code files that have only their direct dependencies.
+// The code files are updated in place.
+func Analyze(codeFiles map[string]CodeFile, view string) (analysis Analysis, err error) {
+
+	// Find the cycles while only direct dependencies are known.
+	analysis.Condensation = FindCondensation(codeFiles)
+
+	// Drive the codeFiles deep into the data structure.
+	analysis.Closure = CalculateDeepCodeFiles(codeFiles, analysis.Condensation)
+
+	// Calculate important numbers.
+	analysis.CodeFiles = codeFiles
+	analysis.MaxReferences = maxReferences(codeFiles)
+	analysis.DirectDensity = CalculateDirectDensity(codeFiles)
+	if analysis.PropogationCost, err = CalculateMetrics(codeFiles, analysis.Closure); err != nil {
+		return Analysis{}, Error(err)
+	}
+
+	// Add cyclic fingerprints.
+	AddCyclicFingerPrints(codeFiles, analysis.Condensation)
+
+	// What is the longest prefix shared by filenames?
+	analysis.Prefix = LongestFilenamePrefix(codeFiles)
+
+	// Compose into cyclical groups.
+	analysis.Groups, analysis.CoreCount, analysis.FileCount = CreateCyclicalGroups(codeFiles, analysis.Condensation)
+
+	// Find the fan in and fan out for the view we want.
+	if view == VIEW_MEDIAN {
+		analysis.VisibilityFanIn, analysis.VisibilityFanOut = FindMedianVisibilityFanInOut(codeFiles)
+	} else {
+		analysis.VisibilityFanIn, analysis.VisibilityFanOut = FindCorePeripheryVisibilityFanInOut(analysis.Groups, analysis.CoreCount)
+	}
+
+	// Partition for display.
+	analysis.Partitions = CreateViewPartions(analysis.Groups, analysis.VisibilityFanIn, analysis.VisibilityFanOut)
+
+	return analysis, nil
+}
+
+// maxReferences finds the most references along any direct dependency.
+func maxReferences(codeFiles map[string]CodeFile) (most int) {
+	for _, codeFile := range codeFiles {
+		for _, weight := range codeFile.Weights {
+			if weight.References > most {
+				most = weight.References
+			}
+		}
+	}
+	return most
+}
+
+// Intensity gets how strongly to shade a dependency, on a log scale from a quarter for a single
+// reference up to one for the most referenced direct dependency. Without a weight it is one.
+func (a Analysis) Intensity(from, to string) (intensity float64) {
+	weight, ok := a.CodeFiles[from].Weights[to]
+	if !ok || weight.References == 0 || a.MaxReferences <= 1 {
+		return 1
+	}
+	return 0.25 + 0.75*math.Log(float64(weight.References))/math.Log(float64(a.MaxReferences))
+}
+
+// IsTestDependency reports whether one code file only depends on another, directly or indirectly, once tests
+// are included. Only the test overlay has such dependencies.
+func (a Analysis) IsTestDependency(from, to string) bool {
+	return a.Production != nil && a.Closure.Reaches(from, to) && !a.Production.Reaches(from, to)
+}
+
+// CollapseToPackages creates a package for every package of the code files, depending on the packages of
+// each file's direct dependencies. Works with the file and declaration granularities.
+func CollapseToPackages(codeFiles map[string]CodeFile) (packages map[string]CodeFile) {
+
+	packages = map[string]CodeFile{}
+	for _, codeFile := range codeFiles {
+		packageName := packageNodeName(codeFile.Name)
+		if _, ok := packages[packageName]; !ok {
+			packages[packageName] = CodeFile{
+				Name:          packageName,
+				DependsOn:     map[string]bool{},
+				TestDependsOn: map[string]bool{},
+				Weights:       map[string]EdgeWeight{},
+				Test:          true,
+				Generated:     true,
+			}
+		}
+		// A package is only a test, or generated, if all of its files are.
+		packageFile := packages[packageName]
+		packageFile.Test = packageFile.Test && codeFile.Test
+		packageFile.Generated = packageFile.Generated && codeFile.Generated
+		packageFile.Lines += codeFile.Lines
+		packages[packageName] = packageFile
+		for dependsOnName := range codeFile.DependsOn {
+			if dependsOnPackageName := packageNodeName(dependsOnName); dependsOnPackageName != packageName {
+				packages[packageName].DependsOn[dependsOnPackageName] = true
+			}
+		}
+		for dependsOnName := range codeFile.TestDependsOn {
+			if dependsOnPackageName := packageNodeName(dependsOnName); dependsOnPackageName != packageName {
+				packages[packageName].TestDependsOn[dependsOnPackageName] = true
+			}
+		}
+
+		// A declaration referenced from more than one file counts once for each.
+		for dependsOnName, weight := range codeFile.Weights {
+			if dependsOnPackageName := packageNodeName(dependsOnName); dependsOnPackageName != packageName {
+				packageWeight := packages[packageName].Weights[dependsOnPackageName]
+				packageWeight.Symbols += weight.Symbols
+				packageWeight.References += weight.References
+				packages[packageName].Weights[dependsOnPackageName] = packageWeight
+			}
+		}
+	}
+
+	// A dependency of both production code and tests is a production dependency.
+	for _, packageFile := range packages {
+		for dependsOnName := range packageFile.TestDependsOn {
+			if packageFile.DependsOn[dependsOnName] {
+				delete(packageFile.TestDependsOn, dependsOnName)
+			}
+		}
+	}
+
+	return packages
+}
+
+// nodePackage gets the package of a node name of any granularity.
+func nodePackage(name, granularity string) (packageName string) {
+	if granularity == GRANULARITY_PACKAGE {
+		return name
+	}
+	return packageNodeName(name)
+}
+
+// packageNodeName gets the package of a file or declaration node name.
+func packageNodeName(name string) (packageName string) {
+	// Declaration names follow the filename after a colon but never have a slash.
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name = name[:i]
+	}
+	return path.Dir(name)
+}
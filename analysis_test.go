@@ -0,0 +1,61 @@
+package technical_debt
+
+import (
+	. "gopkg.in/check.v1" // https://labix.org/gocheck
+)
+
+// Create a suite.
+type AnalysisSuite struct{}
+
+var _ = Suite(&AnalysisSuite{})
+
+// Add the tests.
+
+func (s *AnalysisSuite) Test_CollapseToPackages(c *C) {
+	codeFiles := testCodeFiles(map[string][]string{
+		"a/x/one.go":      {"a/x/two.go", "a/y/three.go:Three"},
+		"a/x/two.go":      {"a/y/three.go:Three"},
+		"a/y/three.go:T":  {},
+		"a/y/three.go:U":  {"a/z/four.go"},
+		"a/z/four.go":     {"a/y/three.go:T"},
+		"a/z/sub/five.go": {},
+	})
+
+	packages := CollapseToPackages(codeFiles)
+	c.Assert(len(packages), Equals, 4)
+	c.Check(dependsOnNames(packages["a/x"]), DeepEquals, []string{"a/y"})
+	c.Check(dependsOnNames(packages["a/y"]), DeepEquals, []string{"a/z"})
+	c.Check(dependsOnNames(packages["a/z"]), DeepEquals, []string{"a/y"})
+	c.Check(dependsOnNames(packages["a/z/sub"]), IsNil)
+
+	// The packages form a cycle at the core.
+	analysis, err := Analyze(packages, VIEW_CORE_PERIPHERY)
+	c.Assert(err, IsNil)
+	c.Check(analysis.FileCount, Equals, 4)
+	c.Check(analysis.CoreCount, Equals, 2)
+	c.Check(analysis.Prefix, Equals, "a")
+}
+
+func (s *AnalysisSuite) Test_DirectDependencies(c *C) {
+	codeFiles := testCodeFiles(map[string][]string{
+		"a": {"b"},
+		"b": {"c"},
+		"c": {},
+		"d": {},
+	})
+
+	analysis, err := Analyze(codeFiles, VIEW_MEDIAN)
+	c.Assert(err, IsNil)
+
+	// The code files keep only their direct dependencies, the deep ones are in the closure.
+	c.Check(codeFiles["a"].DependsOn, DeepEquals, map[string]bool{"b": true})
+	c.Check(analysis.Closure.Reached("a"), DeepEquals, []string{"a", "b", "c"})
+	c.Check(codeFiles["c"].DependedOnBy, DeepEquals, map[string]bool{"b": true})
+	c.Check(codeFiles["c"].VisibilityFanIn, Equals, 3)
+	c.Check(codeFiles["d"].DependsOn, DeepEquals, map[string]bool{})
+
+	// Two direct dependencies in a grid of sixteen.
+	c.Check(analysis.DirectDensity, Equals, 2.0/16.0)
+	c.Check(analysis.PropogationCost, Equals, 7.0/16.0)
+	c.Check(CalculateDirectDensity(map[string]CodeFile{}), Equals, 0.0)
+}
@@ -0,0 +1,189 @@
+package technical_debt
+
+import (
+	"path"
+	"sort"
+	"strings"
+)
+
+const (
+	BREAKDOWN_PACKAGE   = "package"   // The code files of a single package.
+	BREAKDOWN_DIRECTORY = "directory" // The code files of every package in a directory and beneath it.
+)
+
+// Breakdown is the health of one slice of the project, a package or a directory subtree.
+type Breakdown struct {
+	Kind      string       // Either a package or a directory.
+	Name      string       // The import path of the package or directory.
+	FileCount int          // The code files in the slice.
+	Internal  SliceMetrics // Only the dependencies between code files of the slice.
+	Overall   SliceMetrics // The dependencies of code files of the slice on the whole project.
+	DirectIn  int          // The direct dependencies crossing into the slice from outside it.
+	DirectOut int          // The direct dependencies crossing out of the slice.
+}
+
+// SliceMetrics are the metrics of a slice of the project.
+type SliceMetrics struct {
+	PropogationCost float64 // The deep dependencies of the slice, as a fraction of those possible.
+	CoreCount       int     // The most code files of the slice in a single cyclical group.
+	MeanFanIn       float64 // The mean visibility fan in of the code files of the slice.
+	MeanFanOut      float64 // The mean visibility fan out of the code files of the slice.
+	MaxFanIn        int     // The largest visibility fan in of a code file of the slice.
+	MaxFanOut       int     // The largest visibility fan out of a code file of the slice.
+}
+
+// CreateBreakdowns calculates the metrics of every package and directory subtree of analyzed code files.
+// Internally a slice is analyzed as though nothing else existed. Overall, the whole project is seen.
+func CreateBreakdowns(analysis Analysis, granularity string) (breakdowns []Breakdown, err error) {
+
+	// Know which package each code file is in.
+	packageFiles := map[string][]string{}
+	for name := range analysis.CodeFiles {
+		packageName := nodePackage(name, granularity)
+		packageFiles[packageName] = append(packageFiles[packageName], name)
+	}
+	var packageNames []string
+	for packageName := range packageFiles {
+		packageNames = append(packageNames, packageName)
+	}
+	sort.Strings(packageNames)
+
+	// Every directory from the root shared by all packages holds a subtree. A directory with only a
+	// single package is the same as the package.
+	root := commonDirectory(packageNames)
+	directoryPackages := map[string][]string{}
+	for _, packageName := range packageNames {
+		for dir := packageName; dir != "." && dir != "/" && dir != ""; dir = path.Dir(dir) {
+			if root != "" && dir != root && !strings.HasPrefix(dir, root+"/") {
+				break
+			}
+			directoryPackages[dir] = append(directoryPackages[dir], packageName)
+		}
+	}
+
+	for _, packageName := range packageNames {
+		breakdown, err := createBreakdown(analysis, BREAKDOWN_PACKAGE, packageName, packageFiles[packageName])
+		if err != nil {
+			return nil, Error(err)
+		}
+		breakdowns = append(breakdowns, breakdown)
+	}
+
+	var directories []string
+	for dir, packages := range directoryPackages {
+		if len(packages) > 1 {
+			directories = append(directories, dir)
+		}
+	}
+	sort.Strings(directories)
+	for _, dir := range directories {
+		var names []string
+		for _, packageName := range directoryPackages[dir] {
+			names = append(names, packageFiles[packageName]...)
+		}
+		breakdown, err := createBreakdown(analysis, BREAKDOWN_DIRECTORY, dir, names)
+		if err != nil {
+			return nil, Error(err)
+		}
+		breakdowns = append(breakdowns, breakdown)
+	}
+
+	return breakdowns, nil
+}
+
+// commonDirectory finds the deepest directory shared by slash separated paths, which may be one of them.
+func commonDirectory(paths []string) (dir string) {
+	if len(paths) == 0 {
+		return ""
+	}
+	elements := strings.Split(paths[0], "/")
+	for _, p := range paths[1:] {
+		other := strings.Split(p, "/")
+		i := 0
+		for i < len(elements) && i < len(other) && elements[i] == other[i] {
+			i++
+		}
+		elements = elements[:i]
+	}
+	return strings.Join(elements, "/")
+}
+
+// createBreakdown calculates the metrics of a single slice of the project.
+func createBreakdown(analysis Analysis, kind, name string, names []string) (breakdown Breakdown, err error) {
+	breakdown = Breakdown{Kind: kind, Name: name, FileCount: len(names)}
+
+	inSlice := map[string]bool{}
+	for _, name := range names {
+		inSlice[name] = true
+	}
+
+	// Just the slice, with only the direct dependencies between its code files.
+	sliceFiles := map[string]CodeFile{}
+	for _, name := range names {
+		dependsOn := map[string]bool{}
+		for dependsOnName := range analysis.CodeFiles[name].DependsOn {
+			if inSlice[dependsOnName] {
+				dependsOn[dependsOnName] = true
+			} else {
+				breakdown.DirectOut++
+			}
+		}
+		for dependedOnByName := range analysis.CodeFiles[name].DependedOnBy {
+			if !inSlice[dependedOnByName] {
+				breakdown.DirectIn++
+			}
+		}
+		sliceFiles[name] = CodeFile{Name: name, DependsOn: dependsOn}
+	}
+
+	// A slice of the whole project, like the root directory, is already analyzed.
+	sliceAnalysis := analysis
+	if len(names) < len(analysis.CodeFiles) {
+		if sliceAnalysis, err = Analyze(sliceFiles, VIEW_MEDIAN); err != nil {
+			return Breakdown{}, Error(err)
+		}
+	}
+	breakdown.Internal = sliceMetrics(sliceAnalysis.CodeFiles, names, len(names))
+	breakdown.Internal.CoreCount = sliceAnalysis.CoreCount
+
+	// The slice as part of the whole project.
+	breakdown.Overall = sliceMetrics(analysis.CodeFiles, names, len(analysis.CodeFiles))
+	componentCounts := map[int]int{}
+	for _, name := range names {
+		component := analysis.CodeFiles[name].Component
+		componentCounts[component]++
+		if componentCounts[component] > breakdown.Overall.CoreCount {
+			breakdown.Overall.CoreCount = componentCounts[component]
+		}
+	}
+
+	return breakdown, nil
+}
+
+// sliceMetrics calculates the propogation cost and fan in and out statistics of some analyzed code files,
+// out of all the code files they could depend on.
+func sliceMetrics(codeFiles map[string]CodeFile, names []string, possibleCount int) (metrics SliceMetrics) {
+
+	// No files have no metrics.
+	if len(names) == 0 || possibleCount == 0 {
+		return metrics
+	}
+
+	var totalFanIn, totalFanOut int
+	for _, name := range names {
+		codeFile := codeFiles[name]
+		totalFanIn += codeFile.VisibilityFanIn
+		totalFanOut += codeFile.VisibilityFanOut
+		if codeFile.VisibilityFanIn > metrics.MaxFanIn {
+			metrics.MaxFanIn = codeFile.VisibilityFanIn
+		}
+		if codeFile.VisibilityFanOut > metrics.MaxFanOut {
+			metrics.MaxFanOut = codeFile.VisibilityFanOut
+		}
+	}
+	metrics.MeanFanIn = float64(totalFanIn) / float64(len(names))
+	metrics.MeanFanOut = float64(totalFanOut) / float64(len(names))
+	metrics.PropogationCost = float64(totalFanOut) / float64(len(names)*possibleCount)
+
+	return metrics
+}
@@ -0,0 +1,85 @@
+package technical_debt
+
+import (
+	. "gopkg.in/check.v1" // https://labix.org/gocheck
+)
+
+// Create a suite.
+type BreakdownSuite struct{}
+
+var _ = Suite(&BreakdownSuite{})
+
+// Add the tests.
+
+func (s *BreakdownSuite) Test_CreateBreakdowns(c *C) {
+	codeFiles := testCodeFiles(map[string][]string{
+		"r/a/one.go":      {"r/a/two.go", "r/b/three.go"},
+		"r/a/two.go":      {"r/a/one.go"},
+		"r/b/three.go":    {"r/b/sub/four.go"},
+		"r/b/sub/four.go": {},
+	})
+	analysis, err := Analyze(codeFiles, VIEW_MEDIAN)
+	c.Assert(err, IsNil)
+
+	breakdowns, err := CreateBreakdowns(analysis, GRANULARITY_FILE)
+	c.Assert(err, IsNil)
+
+	// Directories holding a single package are the package, so are left out.
+	var names []string
+	for _, breakdown := range breakdowns {
+		names = append(names, breakdown.Kind+" "+breakdown.Name)
+	}
+	c.Assert(names, DeepEquals, []string{
+		"package r/a",
+		"package r/b",
+		"package r/b/sub",
+		"directory r",
+		"directory r/b",
+	})
+
+	// A cycle inside the package.
+	c.Check(breakdowns[0], DeepEquals, Breakdown{
+		Kind:      BREAKDOWN_PACKAGE,
+		Name:      "r/a",
+		FileCount: 2,
+		Internal:  SliceMetrics{PropogationCost: 1, CoreCount: 2, MeanFanIn: 2, MeanFanOut: 2, MaxFanIn: 2, MaxFanOut: 2},
+		Overall:   SliceMetrics{PropogationCost: 1, CoreCount: 2, MeanFanIn: 2, MeanFanOut: 4, MaxFanIn: 2, MaxFanOut: 4},
+		DirectOut: 1,
+	})
+
+	// Cross boundary dependencies only count overall.
+	c.Check(breakdowns[1].Internal.PropogationCost, Equals, 1.0)
+	c.Check(breakdowns[1].Overall.PropogationCost, Equals, 0.5)
+	c.Check(breakdowns[1].DirectIn, Equals, 1)
+	c.Check(breakdowns[1].DirectOut, Equals, 1)
+
+	// The subtree takes in the package beneath it.
+	c.Check(breakdowns[4].FileCount, Equals, 2)
+	c.Check(breakdowns[4].Internal.PropogationCost, Equals, 0.75)
+	c.Check(breakdowns[4].Overall.PropogationCost, Equals, 0.375)
+	c.Check(breakdowns[4].DirectIn, Equals, 1)
+	c.Check(breakdowns[4].DirectOut, Equals, 0)
+
+	// The whole project is the same either way.
+	c.Check(breakdowns[3].Internal, DeepEquals, breakdowns[3].Overall)
+	c.Check(breakdowns[3].Overall.PropogationCost, Equals, analysis.PropogationCost)
+	c.Check(breakdowns[3].Internal.CoreCount, Equals, analysis.CoreCount)
+}
+
+func (s *BreakdownSuite) Test_PackageGranularity(c *C) {
+	codeFiles := testCodeFiles(map[string][]string{
+		"r/a":     {"r/a/sub"},
+		"r/a/sub": {},
+	})
+	analysis, err := Analyze(codeFiles, VIEW_MEDIAN)
+	c.Assert(err, IsNil)
+
+	breakdowns, err := CreateBreakdowns(analysis, GRANULARITY_PACKAGE)
+	c.Assert(err, IsNil)
+	c.Assert(len(breakdowns), Equals, 3)
+	c.Check(breakdowns[0].Name, Equals, "r/a")
+	c.Check(breakdowns[0].FileCount, Equals, 1)
+	c.Check(breakdowns[2].Kind, Equals, BREAKDOWN_DIRECTORY)
+	c.Check(breakdowns[2].Name, Equals, "r/a")
+	c.Check(breakdowns[2].FileCount, Equals, 2)
+}
@@ -0,0 +1,78 @@
+package technical_debt
+
+import (
+	"bufio"
+	"bytes"
+	"go/build"
+	"go/build/constraint"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// buildContext gets the context build constraints are evaluated in.
+func (c Config) buildContext() (context build.Context) {
+	context = build.Default
+	if c.GOOS != "" {
+		context.GOOS = c.GOOS
+	}
+	if c.GOARCH != "" {
+		context.GOARCH = c.GOARCH
+	}
+	context.BuildTags = c.BuildTags
+	return context
+}
+
+// matchesBuild reports whether a file is part of the build described by the config.
+// The contents are passed in so the file is not read again.
+func matchesBuild(config Config, filename string, data []byte) (matches bool, err error) {
+
+	// For all platforms at once, only files that are never built are left out.
+	if config.AllPlatforms {
+		return !ignoredFile(data), nil
+	}
+
+	context := config.buildContext()
+	context.OpenFile = func(path string) (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(data)), nil
+	}
+	if matches, err = context.MatchFile(filepath.Dir(filename), filepath.Base(filename)); err != nil {
+		return false, Error(err)
+	}
+
+	return matches, nil
+}
+
+// ignoredFile reports whether a file has the "ignore" build tag, conventionally used for files that are
+// never part of a build, like code generators.
+func ignoredFile(data []byte) bool {
+
+	// Constraints are only in the comments before the package clause.
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "package ") {
+			break
+		}
+		if !constraint.IsGoBuild(line) && !constraint.IsPlusBuild(line) {
+			continue
+		}
+		expr, err := constraint.Parse(line)
+		if err != nil {
+			continue
+		}
+		var hasIgnore bool
+		expr.Eval(func(tag string) bool {
+			if tag == "ignore" {
+				hasIgnore = true
+			}
+			return false
+		})
+		if hasIgnore {
+			return true
+		}
+	}
+
+	return false
+}
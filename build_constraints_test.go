@@ -0,0 +1,65 @@
+package technical_debt
+
+import (
+	. "gopkg.in/check.v1" // https://labix.org/gocheck
+
+	"sort"
+)
+
+// Create a suite.
+type BuildConstraintsSuite struct{}
+
+var _ = Suite(&BuildConstraintsSuite{})
+
+// Add the tests.
+
+func (s *BuildConstraintsSuite) Test_BuildConstraints(c *C) {
+	tests := []struct {
+		config    Config
+		codeFiles []string
+		dependsOn []string
+	}{
+		{
+			config:    Config{GOOS: "linux", GOARCH: "amd64"},
+			codeFiles: []string{"sys_linux.go", "use.go"},
+			dependsOn: []string{"example.com/platforms/sys/sys_linux.go"},
+		},
+		{
+			config:    Config{GOOS: "windows", GOARCH: "amd64", BuildTags: []string{"special"}},
+			codeFiles: []string{"special.go", "sys_windows.go", "use.go"},
+			dependsOn: []string{"example.com/platforms/sys/sys_windows.go"},
+		},
+		{
+			// Every platform's declaration is depended on, only ignored files are left out.
+			config:    Config{AllPlatforms: true},
+			codeFiles: []string{"special.go", "sys_linux.go", "sys_windows.go", "use.go"},
+			dependsOn: []string{"example.com/platforms/sys/sys_linux.go", "example.com/platforms/sys/sys_windows.go"},
+		},
+	}
+	for i, test := range tests {
+		for _, resolver := range []string{RESOLVER_SYNTAX, RESOLVER_TYPES} {
+			comment := Commentf("Case %v: %v Resolver: %v", i, test, resolver)
+			test.config.Resolver = resolver
+			codeFiles := fixtureCodeFiles(c, "testdata/platforms", test.config)
+
+			var names []string
+			for name := range codeFiles {
+				names = append(names, name[len("example.com/platforms/sys/"):])
+			}
+			sort.Strings(names)
+			c.Check(names, DeepEquals, test.codeFiles, comment)
+			c.Check(dependsOnNames(codeFiles["example.com/platforms/sys/use.go"]), DeepEquals, test.dependsOn, comment)
+		}
+	}
+}
+
+func (s *BuildConstraintsSuite) Test_TwinDeclarations(c *C) {
+	// Each name of a declaration is found on every platform, however the names are grouped.
+	for resolver, codeFiles := range resolverCodeFiles(c, "testdata/platforms", Config{AllPlatforms: true, Granularity: GRANULARITY_DECLARATION}) {
+		comment := Commentf("Resolver: %v", resolver)
+		c.Check(dependsOnNames(codeFiles["example.com/platforms/sys/use.go:Version"]), DeepEquals, []string{
+			"example.com/platforms/sys/sys_linux.go:Major,Minor",
+			"example.com/platforms/sys/sys_windows.go:Minor,Major",
+		}, comment)
+	}
+}
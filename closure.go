@@ -0,0 +1,122 @@
+package technical_debt
+
+import (
+	"math/bits"
+	"sort"
+)
+
+// bitset is a dense set of small non-negative integers.
+type bitset []uint64
+
+// newBitset creates a bitset able to hold the integers below size.
+func newBitset(size int) (set bitset) {
+	return make(bitset, (size+63)/64)
+}
+
+// add puts an integer in the set.
+func (s bitset) add(i int) {
+	s[i/64] |= 1 << uint(i%64)
+}
+
+// has reports whether an integer is in the set.
+func (s bitset) has(i int) bool {
+	return i/64 < len(s) && s[i/64]&(1<<uint(i%64)) != 0
+}
+
+// union adds every integer of another set no larger than this one.
+func (s bitset) union(other bitset) {
+	for i, word := range other {
+		s[i] |= word
+	}
+}
+
+// each calls a function with every integer in the set, in order.
+func (s bitset) each(f func(i int)) {
+	for wordIndex, word := range s {
+		for word != 0 {
+			bit := bits.TrailingZeros64(word)
+			f(wordIndex*64 + bit)
+			word &= word - 1
+		}
+	}
+}
+
+// Closure is the transitive closure of a dependency graph. Every code file in a component reaches
+// the same code files, so only the components reached by each component are kept.
+type Closure struct {
+	Condensation Condensation
+	reaches      []bitset // The components reached by each component, including itself.
+	fanOut       []int    // The code files reached by each component.
+	fanIn        []int    // The code files reaching each component.
+}
+
+// NewClosure calculates the transitive closure over the condensation of a dependency graph.
+func NewClosure(condensation Condensation) (closure Closure) {
+
+	components := condensation.Components
+	closure = Closure{
+		Condensation: condensation,
+		reaches:      make([]bitset, len(components)),
+		fanOut:       make([]int, len(components)),
+		fanIn:        make([]int, len(components)),
+	}
+
+	// Components only depend on components before them, so each bitset only needs to reach as far as
+	// its own index, and everything it depends on is already complete.
+	for i, component := range components {
+		reach := newBitset(i + 1)
+		reach.add(i)
+		for dependsOn := range component.DependsOn {
+			reach.union(closure.reaches[dependsOn])
+		}
+		closure.reaches[i] = reach
+	}
+
+	// Count the files at both ends of every path.
+	for i, reach := range closure.reaches {
+		size := len(components[i].Names)
+		reach.each(func(j int) {
+			closure.fanOut[i] += len(components[j].Names)
+			closure.fanIn[j] += size
+		})
+	}
+
+	return closure
+}
+
+// Reaches reports whether one code file depends on another, directly or indirectly.
+// Every code file reaches itself, and code files outside the graph reach nothing.
+func (c Closure) Reaches(from, to string) bool {
+	fromComponent, fromFound := c.Condensation.ComponentOf[from]
+	toComponent, toFound := c.Condensation.ComponentOf[to]
+	return fromFound && toFound && c.reaches[fromComponent].has(toComponent)
+}
+
+// Reached lists the sorted code files a code file reaches, including itself.
+func (c Closure) Reached(name string) (names []string) {
+	names = []string{}
+	component, ok := c.Condensation.ComponentOf[name]
+	if !ok {
+		return names
+	}
+	c.reaches[component].each(func(reached int) {
+		names = append(names, c.Condensation.Components[reached].Names...)
+	})
+	sort.Strings(names)
+	return names
+}
+
+// VisibilityFanOut counts the code files a code file reaches, including itself.
+func (c Closure) VisibilityFanOut(name string) int {
+	return c.fanOut[c.Condensation.ComponentOf[name]]
+}
+
+// VisibilityFanIn counts the code files reaching a code file, including itself.
+func (c Closure) VisibilityFanIn(name string) int {
+	return c.fanIn[c.Condensation.ComponentOf[name]]
+}
+
+// EachReached calls a function with every component a component reaches, including itself.
+func (c Closure) EachReached(component int, f func(reached int)) {
+	c.reaches[component].each(f)
+}
@@ -0,0 +1,119 @@
+package technical_debt
+
+import (
+	. "gopkg.in/check.v1" // https://labix.org/gocheck
+
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// Create a suite.
+type ClosureSuite struct{}
+
+var _ = Suite(&ClosureSuite{})
+
+// Add the tests.
+
+func (s *ClosureSuite) Test_Bitset(c *C) {
+	set := newBitset(130)
+	c.Check(len(set), Equals, 3)
+	for _, i := range []int{0, 63, 64, 129} {
+		set.add(i)
+	}
+	var members []int
+	set.each(func(i int) { members = append(members, i) })
+	c.Check(members, DeepEquals, []int{0, 63, 64, 129})
+	c.Check(set.has(64), Equals, true)
+	c.Check(set.has(65), Equals, false)
+	c.Check(set.has(500), Equals, false)
+
+	// A smaller set unions into a larger one.
+	other := newBitset(3)
+	other.add(2)
+	set.union(other)
+	c.Check(set.has(2), Equals, true)
+}
+
+func (s *ClosureSuite) Test_ClosureMatchesSearch(c *C) {
+	codeFiles := randomCodeFiles(300, 3, 0.05)
+	closure := NewClosure(FindCondensation(codeFiles))
+
+	for from := range codeFiles {
+		// Search the graph the slow way.
+		reached := map[string]bool{from: true}
+		pending := []string{from}
+		for len(pending) > 0 {
+			name := pending[len(pending)-1]
+			pending = pending[:len(pending)-1]
+			for dependsOnName := range codeFiles[name].DependsOn {
+				if !reached[dependsOnName] {
+					reached[dependsOnName] = true
+					pending = append(pending, dependsOnName)
+				}
+			}
+		}
+
+		c.Assert(closure.VisibilityFanOut(from), Equals, len(reached), Commentf("From: %s", from))
+		for to := range codeFiles {
+			c.Assert(closure.Reaches(from, to), Equals, reached[to], Commentf("From: %s To: %s", from, to))
+		}
+	}
+}
+
+func BenchmarkClosure1k(b *testing.B)  { benchmarkClosure(b, 1000, 0.01) }
+func BenchmarkClosure10k(b *testing.B) { benchmarkClosure(b, 10000, 0.01) }
+func BenchmarkClosure50k(b *testing.B) { benchmarkClosure(b, 50000, 0.01) }
+
+// Without cycles every file is its own component, the most work for the closure.
+func BenchmarkClosure50kAcyclic(b *testing.B) { benchmarkClosure(b, 50000, 0) }
+
+// benchmarkClosure finds the condensation and closure of a graph shaped like a large code base.
+func benchmarkClosure(b *testing.B, nodeCount int, backEdgeFraction float64) {
+	codeFiles := randomCodeFiles(nodeCount, 5, backEdgeFraction)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		closure := NewClosure(FindCondensation(codeFiles))
+		if closure.VisibilityFanOut("n0") < 1 {
+			b.Fatal("a node always reaches itself")
+		}
+	}
+}
+
+func BenchmarkAnalyze50k(b *testing.B)        { benchmarkAnalyze(b, 50000, 0.01) }
+func BenchmarkAnalyze50kAcyclic(b *testing.B) { benchmarkAnalyze(b, 50000, 0) }
+
+// benchmarkAnalyze runs the whole algorithm over a graph shaped like a large code base.
+func benchmarkAnalyze(b *testing.B, nodeCount int, backEdgeFraction float64) {
+	codeFiles := randomCodeFiles(nodeCount, 5, backEdgeFraction)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		analysis, err := Analyze(codeFiles, VIEW_MEDIAN)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if analysis.FileCount != nodeCount {
+			b.Fatal("every node is analyzed")
+		}
+	}
+}
+
+// randomCodeFiles creates a repeatable graph where most dependencies point at earlier nodes,
+// like layered code, with a fraction pointing back to form cycles.
+func randomCodeFiles(nodeCount, edgesPerNode int, backEdgeFraction float64) (codeFiles map[string]CodeFile) {
+	random := rand.New(rand.NewSource(1))
+	codeFiles = make(map[string]CodeFile, nodeCount)
+	for i := 0; i < nodeCount; i++ {
+		name := fmt.Sprintf("n%d", i)
+		codeFile := CodeFile{Name: name, DependsOn: map[string]bool{}}
+		for j := 0; j < edgesPerNode && i > 0; j++ {
+			to := random.Intn(i)
+			if random.Float64() < backEdgeFraction {
+				to = i + random.Intn(nodeCount-i)
+			}
+			codeFile.DependsOn[fmt.Sprintf("n%d", to)] = true
+		}
+		codeFiles[name] = codeFile
+	}
+	return codeFiles
+}
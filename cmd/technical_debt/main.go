@@ -2,76 +2,333 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"strings"
+	"text/tabwriter"
 	"text/template"
 
 	"github.com/glemzurg/technical_debt"
 )
 
+// The exit codes, one for each stage that can fail.
+const (
+	EXIT_USAGE    = 2 // The command line is wrong.
+	EXIT_CONFIG   = 3 // The config could not be loaded.
+	EXIT_SOURCE   = 4 // The source code could not be found or parsed.
+	EXIT_ANALYSIS = 5 // The dependency graph could not be analyzed.
+	EXIT_OUTPUT   = 6 // The results could not be written.
+)
+
+// showStack includes stack dumps with any error.
+var showStack bool
+
 func main() {
 	var err error
 
 	// Example call: go/bin/technical_debt -config /path/to/technical_debt/root/config/config.json
 
-	var configFilename string
+	var configFilename, outputs string
 	flag.StringVar(&configFilename, "config", "", "the config for this technical debt")
+	flag.StringVar(&outputs, "outputs", "", "comma separated outputs to write, overriding the config (grid, json, csv, tsv, dot, graphml, html)")
+	flag.BoolVar(&showStack, "stack", false, "show the stack dump with any error")
 	flag.Parse()
 
 	if configFilename == "" {
-		panic(`-config required`)
+		fmt.Fprintln(os.Stderr, "-config required")
+		flag.Usage()
+		os.Exit(EXIT_USAGE)
 	}
 
 	config, err := technical_debt.LoadConfig(configFilename)
 	if err != nil {
-		panic(err.Error())
+		exit(EXIT_CONFIG, err)
+	}
+	if outputs != "" {
+		if config.Outputs, err = technical_debt.ParseOutputs(outputs); err != nil {
+			exit(EXIT_USAGE, err)
+		}
 	}
 
 	fmt.Printf("\n\nconfig: \n%+v\n\n", config)
 
+	// Find the modules that make up the project.
+	modules, err := config.Modules()
+	if err != nil {
+		exit(EXIT_CONFIG, err)
+	}
+
 	// Get all the paths we are graphing.
-	packagePaths, err := technical_debt.PackagePaths(config.Gopath, config.Paths)
+	packages, err := technical_debt.PackagePaths(modules, config)
 	if err != nil {
-		panic(err.Error())
+		exit(EXIT_SOURCE, err)
 	}
 
 	// Process each package in out project.
-	folders, err := technical_debt.ProcessPackage(config.Gopath, packagePaths, config.Paths, config.IncludeTests)
+	folders, diagnostics, err := technical_debt.ProcessPackage(config, packages, config.ProjectPaths(modules))
 	if err != nil {
-		panic(err.Error())
+		exit(EXIT_SOURCE, err)
+	}
+
+	// Any errors tolerated in the source are reported, since the results may be missing dependencies.
+	if len(diagnostics) > 0 {
+		fmt.Printf("diagnostics: %d\n", len(diagnostics))
+		for _, diagnostic := range diagnostics {
+			fmt.Printf("\t%s\n", diagnostic.Error())
+		}
+		fmt.Println()
 	}
 
 	// Create the codeFiles.
-	codeFiles := technical_debt.CreateCodeFiles(folders)
+	codeFiles := technical_debt.CreateCodeFiles(folders, config)
+
+	// The package view is collapsed from the direct dependencies, before they are driven deep.
+	var packageFiles map[string]technical_debt.CodeFile
+	if config.PackageView {
+		packageFiles = technical_debt.CollapseToPackages(codeFiles)
+	}
+
+	// Run the algorithm.
+	analysis, production, err := analyze(config, codeFiles)
+	if err != nil {
+		exit(EXIT_ANALYSIS, err)
+	}
+	report(config, "", analysis, production)
+	var breakdowns []technical_debt.Breakdown
+	if config.Breakdown {
+		if breakdowns, err = technical_debt.CreateBreakdowns(production, config.Granularity); err != nil {
+			exit(EXIT_ANALYSIS, err)
+		}
+		reportBreakdowns(breakdowns)
+	}
+	if err = writeOutputs(config, analysis, production, breakdowns, diagnostics, ""); err != nil {
+		exit(EXIT_OUTPUT, err)
+	}
+
+	// The same again, at the level of packages.
+	if config.PackageView {
+		packageAnalysis, packageProduction, err := analyze(config, packageFiles)
+		if err != nil {
+			exit(EXIT_ANALYSIS, err)
+		}
+		report(config, "package ", packageAnalysis, packageProduction)
+		packageConfig := config
+		packageConfig.Granularity = technical_debt.GRANULARITY_PACKAGE
+		if err = writeOutputs(packageConfig, packageAnalysis, packageProduction, nil, diagnostics, "-packages"); err != nil {
+			exit(EXIT_OUTPUT, err)
+		}
+	}
+}
+
+// analyze runs the algorithm over the code files. With the test overlay the production code is
+// analyzed on its own as well, otherwise any tests are analyzed as ordinary code.
+func analyze(config technical_debt.Config, codeFiles map[string]technical_debt.CodeFile) (analysis, production technical_debt.Analysis, err error) {
 
-	// Drive the codeFiles deep into the data structure.
-	technical_debt.CalculateDeepCodeFiles(codeFiles)
+	if analysis, err = technical_debt.Analyze(technical_debt.WithTests(codeFiles), config.View); err != nil {
+		return technical_debt.Analysis{}, technical_debt.Analysis{}, technical_debt.Error(err)
+	}
+	if config.Distances {
+		analysis.Distances = technical_debt.CalculateDistances(analysis, config.DistanceDecay())
+	}
+	if config.TestMode() != technical_debt.TESTS_OVERLAY {
+		return analysis, analysis, nil
+	}
+
+	if production, err = technical_debt.Analyze(technical_debt.WithoutTests(codeFiles), config.View); err != nil {
+		return technical_debt.Analysis{}, technical_debt.Analysis{}, technical_debt.Error(err)
+	}
+	if config.Distances {
+		production.Distances = technical_debt.CalculateDistances(production, config.DistanceDecay())
+	}
+	technical_debt.OverlayTests(&analysis, production)
+
+	return analysis, production, nil
+}
+
+// report prints the metrics of an analysis, both with and without tests for the test overlay.
+func report(config technical_debt.Config, label string, analysis, production technical_debt.Analysis) {
+
+	fmt.Println(label+"propogation cost:", production.PropogationCost)
+	fmt.Println(label+"direct density:", production.DirectDensity)
+	if config.Distances {
+		reportDistances(label, production.Distances)
+	}
+	fmt.Printf("%score size: %d / %d == %.2f\n", label, production.CoreCount, production.FileCount, float64(production.CoreCount)/float64(production.FileCount))
 
-	// Calculate important numbers.
-	propogationCost := technical_debt.CalculateMetrics(codeFiles)
+	if config.TestMode() == technical_debt.TESTS_OVERLAY {
+		fmt.Println(label+"propogation cost with tests:", analysis.PropogationCost)
+		fmt.Println(label+"direct density with tests:", analysis.DirectDensity)
+		if config.Distances {
+			reportDistances(label+"with tests ", analysis.Distances)
+		}
+		fmt.Printf("%score size with tests: %d / %d == %.2f\n", label, analysis.CoreCount, analysis.FileCount, float64(analysis.CoreCount)/float64(analysis.FileCount))
 
-	// Add cyclic fingerprints.
-	technical_debt.AddCyclicFingerPrints(codeFiles)
+		// The production code that tests lean on the most.
+		hubs := technical_debt.TestHubs(analysis.CodeFiles, 10)
+		if len(hubs) > 0 {
+			fmt.Printf("%stest hubs:\n", label)
+			for _, hub := range hubs {
+				fmt.Printf("\t%d\t%s\n", hub.TestFanIn, hub.Name)
+			}
+		}
+	}
+	fmt.Println()
+}
 
-	// What is the longest prefix shared by filenames?
-	prefix := technical_debt.LongestFilenamePrefix(codeFiles)
+// reportBreakdowns prints the metrics of every package and directory subtree.
+func reportBreakdowns(breakdowns []technical_debt.Breakdown) {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(writer, "kind\tname\tfiles\tinternal cost\tinternal core\tcost\tcore\tmean fan in\tmean fan out\tdirect in\tdirect out")
+	for _, breakdown := range breakdowns {
+		fmt.Fprintf(writer, "%s\t%s\t%d\t%.3f\t%d\t%.3f\t%d\t%.1f\t%.1f\t%d\t%d\n",
+			breakdown.Kind, breakdown.Name, breakdown.FileCount,
+			breakdown.Internal.PropogationCost, breakdown.Internal.CoreCount,
+			breakdown.Overall.PropogationCost, breakdown.Overall.CoreCount,
+			breakdown.Overall.MeanFanIn, breakdown.Overall.MeanFanOut,
+			breakdown.DirectIn, breakdown.DirectOut)
+	}
+	writer.Flush()
+	fmt.Println()
+}
 
-	// Compose into cyclical groups.
-	groups, coreCount, fileCount := technical_debt.CreateCyclicalGroups(codeFiles)
+// reportDistances prints how far apart dependencies are.
+func reportDistances(label string, distances technical_debt.Distances) {
+	fmt.Printf("%sdecayed propogation cost (%.2f): %v\n", label, distances.Decay, distances.DecayedPropogationCost)
+	fmt.Printf("%sdistance: mean %.2f, longest %d\n", label, distances.MeanDistance, distances.MaxDistance)
+	for distance := 1; distance < len(distances.Histogram); distance++ {
+		fmt.Printf("\t%d\t%d\n", distance, distances.Histogram[distance])
+	}
+}
 
-	// Find the fan in and fan out for the view we want.
-	var visibilityFanIn, visibilityFanOut int
-	if config.View == technical_debt.VIEW_MEDIAN {
-		visibilityFanIn, visibilityFanOut = technical_debt.FindMedianVisibilityFanInOut(codeFiles)
+// exit reports an error and stops with the exit code.
+func exit(code int, err error) {
+	if showStack {
+		fmt.Fprintln(os.Stderr, err.Error())
 	} else {
-		visibilityFanIn, visibilityFanOut = technical_debt.FindCorePeripheryVisibilityFanInOut(groups, coreCount)
+		fmt.Fprintln(os.Stderr, technical_debt.ErrorMessage(err))
+	}
+	os.Exit(code)
+}
+
+// writeOutputs writes every output of an analysis the config asks for into the output folder,
+// with a suffix on each filename.
+func writeOutputs(config technical_debt.Config, analysis, production technical_debt.Analysis, breakdowns []technical_debt.Breakdown, diagnostics []technical_debt.SourceError, suffix string) (err error) {
+	if config.Writes(technical_debt.OUTPUT_GRID) {
+		if err = writeGrid(config, analysis, "grid"+suffix+".svg"); err != nil {
+			return technical_debt.Error(err)
+		}
 	}
+	if config.Writes(technical_debt.OUTPUT_JSON) {
+		if err = writeReport(technical_debt.CreateReport(config, analysis, production, breakdowns, diagnostics), config.RootPath+"/output/report"+suffix+".json"); err != nil {
+			return technical_debt.Error(err)
+		}
+	}
+	if config.Writes(technical_debt.OUTPUT_HTML) {
+		if err = writeViewer(config, technical_debt.CreateReport(config, analysis, production, breakdowns, diagnostics), "viewer"+suffix+".html"); err != nil {
+			return technical_debt.Error(err)
+		}
+	}
+	if config.Writes(technical_debt.OUTPUT_CSV) {
+		if err = writeSpreadsheet(config, analysis, config.RootPath+"/output/files"+suffix+".csv", ','); err != nil {
+			return technical_debt.Error(err)
+		}
+	}
+	if config.Writes(technical_debt.OUTPUT_TSV) {
+		if err = writeSpreadsheet(config, analysis, config.RootPath+"/output/files"+suffix+".tsv", '\t'); err != nil {
+			return technical_debt.Error(err)
+		}
+	}
+	for _, kind := range []string{technical_debt.GRAPH_DIRECT, technical_debt.GRAPH_CONDENSATION} {
+		graph := technical_debt.CreateGraph(analysis, config.Granularity, kind)
+		if config.Writes(technical_debt.OUTPUT_DOT) {
+			if err = writeGraph(graph, config.RootPath+"/output/graph-"+kind+suffix+".dot", config.Clusters, technical_debt.WriteDot); err != nil {
+				return technical_debt.Error(err)
+			}
+		}
+		if config.Writes(technical_debt.OUTPUT_GRAPHML) {
+			if err = writeGraph(graph, config.RootPath+"/output/graph-"+kind+suffix+".graphml", config.Clusters, technical_debt.WriteGraphML); err != nil {
+				return technical_debt.Error(err)
+			}
+		}
+	}
+	return nil
+}
+
+// writeGraph writes a dependency graph in the format of a writer.
+func writeGraph(graph technical_debt.Graph, filename string, clusters bool, write func(io.Writer, technical_debt.Graph, bool) error) (err error) {
+	var outputBuffer bytes.Buffer
+	if err = write(&outputBuffer, graph, clusters); err != nil {
+		return technical_debt.Error(err)
+	}
+	if err = ioutil.WriteFile(filename, outputBuffer.Bytes(), os.ModePerm); err != nil {
+		return technical_debt.Error(err)
+	}
+	return nil
+}
+
+// writeSpreadsheet writes the metrics of every code file as a spreadsheet.
+func writeSpreadsheet(config technical_debt.Config, analysis technical_debt.Analysis, filename string, comma rune) (err error) {
+	var outputBuffer bytes.Buffer
+	if err = technical_debt.WriteSpreadsheet(&outputBuffer, analysis, config.Granularity, comma); err != nil {
+		return technical_debt.Error(err)
+	}
+	if err = ioutil.WriteFile(filename, outputBuffer.Bytes(), os.ModePerm); err != nil {
+		return technical_debt.Error(err)
+	}
+	return nil
+}
+
+// writeViewer writes the interactive grid, with the report of the analysis inside it.
+func writeViewer(config technical_debt.Config, report technical_debt.Report, filename string) (err error) {
+
+	// The report is json, which is also javascript. Marshalling escapes anything that could end the script.
+	// The viewer finds the deep dependencies of what is in view itself.
+	data, err := json.Marshal(report.WithoutDeepDependencies())
+	if err != nil {
+		return technical_debt.Error(err)
+	}
+
+	t, err := template.ParseFiles(config.RootPath + "/template/viewer.template")
+	if err != nil {
+		return technical_debt.Error(err)
+	}
+	var outputBuffer bytes.Buffer
+	err = t.Execute(&outputBuffer, struct {
+		Title  string
+		Report string
+	}{
+		Title:  "technical debt " + report.Prefix,
+		Report: string(data),
+	})
+	if err != nil {
+		return technical_debt.Error(err)
+	}
+
+	if err = ioutil.WriteFile(config.RootPath+"/output/"+filename, outputBuffer.Bytes(), os.ModePerm); err != nil {
+		return technical_debt.Error(err)
+	}
+
+	return nil
+}
 
-	// Partition for display.
-	partitions := technical_debt.CreateViewPartions(groups, visibilityFanIn, visibilityFanOut)
+// writeReport writes the json report of an analysis.
+func writeReport(report technical_debt.Report, filename string) (err error) {
+	data, err := json.MarshalIndent(report, "", "\t")
+	if err != nil {
+		return technical_debt.Error(err)
+	}
+	if err = ioutil.WriteFile(filename, append(data, '\n'), os.ModePerm); err != nil {
+		return technical_debt.Error(err)
+	}
+	return nil
+}
+
+// writeGrid writes the grid of an analysis into the output folder.
+func writeGrid(config technical_debt.Config, analysis technical_debt.Analysis, filename string) (err error) {
 
 	// Define some function for our template.
 	funcMap := template.FuncMap{
@@ -82,15 +339,28 @@ func main() {
 			return a * b
 		},
 		"isDependency": func(file, potential technical_debt.CodeFile) bool {
-			return file.DependsOn[potential.Name]
+			return analysis.Closure.Reaches(file.Name, potential.Name)
+		},
+		"isDirectDependency": func(file, potential technical_debt.CodeFile) bool {
+			// Every file depends on itself, which is shown like a direct dependency.
+			return file.DependsOn[potential.Name] || file.Name == potential.Name
+		},
+		"isTestDependency": func(file, potential technical_debt.CodeFile) bool {
+			return analysis.IsTestDependency(file.Name, potential.Name)
+		},
+		"intensity": func(file, potential technical_debt.CodeFile) string {
+			return fmt.Sprintf("%.2f", analysis.Intensity(file.Name, potential.Name))
 		},
 		"trimPrefix": func(filename string) string {
-			return strings.TrimPrefix(filename, prefix+"/")
+			return strings.TrimPrefix(filename, analysis.Prefix+"/")
 		},
 	}
 
 	// Load the template.
-	t := template.Must(template.New("grid.template").Funcs(funcMap).ParseFiles(config.RootPath + "/template/grid.template"))
+	t, err := template.New("grid.template").Funcs(funcMap).ParseFiles(config.RootPath + "/template/grid.template")
+	if err != nil {
+		return technical_debt.Error(err)
+	}
 
 	// Get the output bytes.
 	var outputBuffer bytes.Buffer // A Buffer needs no initialization.
@@ -100,18 +370,17 @@ func main() {
 		FileCount  int
 		Partitions []technical_debt.Partition
 	}{
-		FileCount:  fileCount,
-		Partitions: partitions,
+		FileCount:  analysis.FileCount,
+		Partitions: analysis.Partitions,
 	})
 	if err != nil {
-		panic(err.Error())
+		return technical_debt.Error(err)
 	}
 
 	// Write the text to a file.
-	if err = ioutil.WriteFile(config.RootPath+"/output/grid.svg", outputBuffer.Bytes(), os.ModePerm); err != nil {
-		panic(err.Error())
+	if err = ioutil.WriteFile(config.RootPath+"/output/"+filename, outputBuffer.Bytes(), os.ModePerm); err != nil {
+		return technical_debt.Error(err)
 	}
 
-	fmt.Println("propogation cost:", propogationCost)
-	fmt.Printf("core size: %d / %d == %.2f\n\n", coreCount, fileCount, float64(coreCount)/float64(fileCount))
+	return nil
 }
@@ -4,27 +4,83 @@ import (
 // "fmt"
 )
 
-// CodeFile is a single file in the dependency graph.
+const (
+	DECLARATION_KIND_METHOD = "method" // Alongside the ast.ObjKind names of package level declarations.
+)
+
+// CodeFile is a single node in the dependency graph. By default each node is a file.
 type CodeFile struct {
-	Name              string          // The file name with path.
-	DependsOn         map[string]bool // The files this file depends on. Set represented as a map.
-	DependedOnBy      map[string]bool // The files this file depends on. Set represented as a map.
+	Name              string                // The file name with path, or the node name for other granularities.
+	DependsOn         map[string]bool       // The files this file depends on directly. Set represented as a map. The deep dependencies are in the closure.
+	DependedOnBy      map[string]bool       // The files depending directly on this file. Set represented as a map.
+	TestDependsOn     map[string]bool       // The files this file depends on only in tests. Set represented as a map.
+	Weights           map[string]EdgeWeight // How strongly this file directly depends on each file.
+	Test              bool                  // The node is only built by go test.
+	Generated         bool                  // The node is generated code, marked to be shown apart.
+	Lines             int                   // The lines of code, without blank lines and comments.
+	TestFanIn         int                   // How many more files depend on this file deeply once tests are included.
 	VisibilityFanIn   int
 	VisibilityFanOut  int
-	CyclicFingerprint string // The md5 fingerprint of the dependencies.
+	CyclicFingerprint string // Identifies the cyclical group, the first name of its component.
+	Component         int    // The index of the strongly connected component in the condensation.
 	Index             int    // The position in in the whole display this code file is (starting at zero).
 }
 
-// CreateCodeFiles creates the dependency map for all the files.
-func CreateCodeFiles(folders []packageFolder) (codeFiles map[string]CodeFile) {
+// selectedLocations finds the declarations of a name selected from a type: a method of the type, a field or
+// interface method declared in the type itself, or else whatever is promoted from the types embedded in it.
+func selectedLocations(declarationLookup map[string]map[string][]declarationLocation, typeLookup map[typeReference][]fileType, receiver typeReference, name string, seen map[typeReference]bool) (locations []declarationLocation) {
+	if seen[receiver] {
+		return nil
+	}
+	seen[receiver] = true
+
+	if locations = declarationLookup[receiver.importPath][receiver.name+"."+name]; len(locations) > 0 {
+		return locations
+	}
+	for _, fileType := range typeLookup[receiver] {
+		if fileType.members[name] {
+			return declarationLookup[receiver.importPath][receiver.name]
+		}
+	}
+	for _, fileType := range typeLookup[receiver] {
+		for _, embedded := range fileType.embeds {
+			locations = append(locations, selectedLocations(declarationLookup, typeLookup, embedded, name, seen)...)
+		}
+	}
+	return locations
+}
+
+// EdgeWeight is how strongly one code file directly depends on another.
+type EdgeWeight struct {
+	Symbols    int // How many distinct declarations are referenced.
+	References int // How many times they are referenced.
+}
+
+// CreateCodeFiles creates the dependency map for all the files, or for the declarations or packages
+// depending on the granularity. Only the test overlay keeps the dependencies of tests apart.
+func CreateCodeFiles(folders []packageFolder, config Config) (codeFiles map[string]CodeFile) {
+	var granularity string = config.Granularity
+	var overlayTests bool = config.TestMode() == TESTS_OVERLAY
 
 	// Create lookup of which declarations are in which files.
-	declarationLookup := map[string]map[string]string{}
+	// A name is declared more than once when files for different platforms are analyzed together.
+	// Structs and interfaces are also looked up for the names declared in them and the types they embed.
+	declarationLookup := map[string]map[string][]declarationLocation{}
+	typeLookup := map[typeReference][]fileType{}
+	fileLookup := map[string][]declarationLocation{}
 	for _, folder := range folders {
-		declarationLookup[folder.importPath] = map[string]string{}
+		if declarationLookup[folder.importPath] == nil {
+			declarationLookup[folder.importPath] = map[string][]declarationLocation{}
+		}
 		for _, file := range folder.files {
+			fileLookup[folder.importPath] = append(fileLookup[folder.importPath], declarationLocation{importPath: folder.importPath, file: file.name})
 			for _, declaration := range file.declarations {
-				declarationLookup[folder.importPath][declaration.name] = folder.importPath + "/" + file.name
+				location := declarationLocation{importPath: folder.importPath, file: file.name, declaration: declaration.node}
+				declarationLookup[folder.importPath][declaration.name] = append(declarationLookup[folder.importPath][declaration.name], location)
+			}
+			for _, fileType := range file.types {
+				reference := typeReference{importPath: folder.importPath, name: fileType.name}
+				typeLookup[reference] = append(typeLookup[reference], fileType)
 			}
 		}
 	}
@@ -32,26 +88,117 @@ func CreateCodeFiles(folders []packageFolder) (codeFiles map[string]CodeFile) {
 	// fmt.Println("===========")
 	// for importPath, declarations := range declarationLookup {
 	// 	fmt.Printf(importPath + "\n")
-	// 	for declaration, filename := range declarations {
-	// 		fmt.Printf("\t" + declaration + " -> " + filename + "\n")
+	// 	for declaration, locations := range declarations {
+	// 		fmt.Printf("\t" + declaration + " -> " + locations[0].file + "\n")
 	// 	}
 	// }
 
-	// Go through folders and compose the file dependencies.
+	// Every file, declaration or package is a node even if it has no dependencies.
 	codeFiles = map[string]CodeFile{}
+	addCodeFile := func(name string, test, generated bool, lines int) {
+		codeFile, ok := codeFiles[name]
+		if !ok {
+			codeFile = CodeFile{
+				Name:          name,
+				DependsOn:     map[string]bool{},
+				TestDependsOn: map[string]bool{},
+				Weights:       map[string]EdgeWeight{},
+				Test:          test,
+				Generated:     generated,
+			}
+		}
+		// A package is only a test, or generated, if all of its files are.
+		codeFile.Test = codeFile.Test && test
+		codeFile.Generated = codeFile.Generated && generated
+		codeFile.Lines += lines
+		codeFiles[name] = codeFile
+	}
+	symbols := map[Edge]map[string]bool{} // The declarations referenced along each dependency.
+	addDependency := func(from, to declarationLocation, references int) {
+		fromName, toName := from.nodeName(granularity), to.nodeName(granularity)
+		_, fromFound := codeFiles[fromName]
+		_, toFound := codeFiles[toName]
+		if fromFound && toFound && fromName != toName {
+			// Dependencies in test files are kept apart so they can be analyzed with or without tests.
+			if overlayTests && isTestFile(from.file) {
+				codeFiles[fromName].TestDependsOn[toName] = true
+			} else {
+				codeFiles[fromName].DependsOn[toName] = true
+			}
+
+			edge := Edge{From: fromName, To: toName}
+			if symbols[edge] == nil {
+				symbols[edge] = map[string]bool{}
+			}
+			symbols[edge][to.nodeName(GRANULARITY_DECLARATION)] = true
+			weight := codeFiles[fromName].Weights[toName]
+			weight.Symbols = len(symbols[edge])
+			weight.References += references
+			codeFiles[fromName].Weights[toName] = weight
+		}
+	}
 	for _, folder := range folders {
 		for _, file := range folder.files {
-			codeFile := CodeFile{
-				Name:         folder.importPath + "/" + file.name,
-				DependsOn:    map[string]bool{},
-				DependedOnBy: map[string]bool{},
+			location := declarationLocation{importPath: folder.importPath, file: file.name}
+			if granularity == GRANULARITY_DECLARATION {
+				for _, declarationRange := range file.ranges {
+					location.declaration = declarationRange.name
+					addCodeFile(location.nodeName(granularity), isTestFile(file.name), file.generated, declarationRange.lines)
+				}
+			} else {
+				addCodeFile(location.nodeName(granularity), isTestFile(file.name), file.generated, file.lines)
 			}
+		}
+	}
+
+	// Go through folders and compose the dependencies.
+	for _, folder := range folders {
+		for _, file := range folder.files {
 			for _, unresolved := range file.unresolved {
-				if filename, found := declarationLookup[unresolved.packageName][unresolved.name]; found {
-					codeFile.DependsOn[filename] = true
+				from := declarationLocation{importPath: folder.importPath, file: file.name, declaration: unresolved.from}
+				if granularity == GRANULARITY_DECLARATION && unresolved.from == "" {
+					// Not part of any declaration so not part of any node.
+					continue
 				}
+
+				// A package is initialized by all its files. Only files and packages have nodes for this.
+				if unresolved.packageInit {
+					for _, location := range fileLookup[unresolved.packageName] {
+						addDependency(from, location, unresolved.count)
+					}
+					continue
+				}
+
+				// Type checking already knows the declaration, but only sees one of any declared for other platforms.
+				if unresolved.target.file != "" {
+					addDependency(from, unresolved.target, unresolved.count)
+					for _, location := range declarationLookup[unresolved.target.importPath][unresolved.name] {
+						if location != unresolved.target {
+							addDependency(from, location, unresolved.count)
+						}
+					}
+					continue
+				}
+				if unresolved.receiver != "" {
+					receiver := typeReference{importPath: unresolved.packageName, name: unresolved.receiver}
+					for _, location := range selectedLocations(declarationLookup, typeLookup, receiver, unresolved.name, map[typeReference]bool{}) {
+						addDependency(from, location, unresolved.count)
+					}
+					continue
+				}
+				for _, location := range declarationLookup[unresolved.packageName][unresolved.name] {
+					addDependency(from, location, unresolved.count)
+				}
+			}
+		}
+	}
+
+	// A dependency of both production code and tests is a production dependency.
+	for _, codeFile := range codeFiles {
+		for dependsOnName := range codeFile.TestDependsOn {
+			if codeFile.DependsOn[dependsOnName] {
+				delete(codeFile.TestDependsOn, dependsOnName)
 			}
-			codeFiles[codeFile.Name] = codeFile
 		}
 	}
 
@@ -0,0 +1,51 @@
+package technical_debt
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+// Create a suite.
+type CodeFileSuite struct{}
+
+var _ = Suite(&CodeFileSuite{})
+
+// Add the tests.
+
+func (s *CodeFileSuite) Test_Weights(c *C) {
+	for resolver, codeFiles := range resolverCodeFiles(c, "testdata/generics", Config{}) {
+		comment := Commentf("Resolver: %v", resolver)
+
+		app := codeFiles["example.com/generics/app/app.go"]
+		c.Check(app.Weights["example.com/generics/collections/map.go"], Equals, EdgeWeight{Symbols: 2, References: 2}, comment)
+		c.Check(app.Weights["example.com/generics/collections/map_set.go"], Equals, EdgeWeight{Symbols: 1, References: 1}, comment)
+		// The field selected is part of the type too.
+		c.Check(app.Weights["example.com/generics/collections/pair.go"], Equals, EdgeWeight{Symbols: 1, References: 2}, comment)
+		c.Check(len(app.Weights), Equals, len(app.DependsOn), comment)
+
+		// Both files of the package refer to Filter, and each counts.
+		packages := CollapseToPackages(codeFiles)
+		weight := packages["example.com/generics/app"].Weights["example.com/generics/collections"]
+		c.Check(weight.Symbols, Equals, 7, comment)
+	}
+}
+
+func (s *CodeFileSuite) Test_Intensity(c *C) {
+	codeFiles := testCodeFiles(map[string][]string{
+		"a": {"b", "c"},
+		"b": {"c"},
+		"c": {},
+	})
+	codeFiles["a"].Weights["b"] = EdgeWeight{Symbols: 1, References: 1}
+	codeFiles["a"].Weights["c"] = EdgeWeight{Symbols: 3, References: 10}
+	codeFiles["b"].Weights["c"] = EdgeWeight{Symbols: 2, References: 4}
+
+	analysis, err := Analyze(codeFiles, VIEW_MEDIAN)
+	c.Assert(err, IsNil)
+	c.Check(analysis.MaxReferences, Equals, 10)
+	c.Check(analysis.Intensity("a", "b"), Equals, 0.25)
+	c.Check(analysis.Intensity("a", "c"), Equals, 1.0)
+	c.Check(analysis.Intensity("b", "c") > 0.25 && analysis.Intensity("b", "c") < 1, Equals, true)
+
+	// Without a weight, like a file depending on itself, it is fully shaded.
+	c.Check(analysis.Intensity("a", "a"), Equals, 1.0)
+}
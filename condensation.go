@@ -0,0 +1,146 @@
+package technical_debt
+
+import (
+	"sort"
+)
+
+// Edge is a direct dependency of one code file on another.
+type Edge struct {
+	From string // The code file with the dependency.
+	To   string // The code file depended on.
+}
+
+// Component is a strongly connected component of the dependency graph, code files that all
+// depend on each other directly or indirectly. A code file in no cycle is a component on its own.
+type Component struct {
+	Index         int          // The position in the condensation.
+	Names         []string     // The sorted names of the code files in the component.
+	InternalEdges []Edge       // The sorted direct dependencies between code files of the component.
+	DependsOn     map[int]bool // The indexes of the components directly depended on. Set represented as a map.
+}
+
+// Condensation is the graph of strongly connected components, which has no cycles.
+type Condensation struct {
+	Components  []Component    // Every component comes after all the components it depends on.
+	ComponentOf map[string]int // The component index of each code file.
+}
+
+// FindCondensation finds the strongly connected components of code files that have only their
+// direct dependencies, using Tarjan's algorithm.
+func FindCondensation(codeFiles map[string]CodeFile) (condensation Condensation) {
+
+	// Number the nodes in name order so the results do not depend on map ordering.
+	var names []string
+	for name := range codeFiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	nodeIndexes := make(map[string]int, len(names))
+	for i, name := range names {
+		nodeIndexes[name] = i
+	}
+	edges := make([][]int, len(names))
+	for i, name := range names {
+		for dependsOnName := range codeFiles[name].DependsOn {
+			if j, ok := nodeIndexes[dependsOnName]; ok && j != i {
+				edges[i] = append(edges[i], j)
+			}
+		}
+		sort.Ints(edges[i])
+	}
+
+	// Tarjan's algorithm, without recursion so deep dependency chains cannot exhaust the stack.
+	// Each component is found only after every component it depends on, which is the order we want.
+	const unvisited = -1
+	visitOrder := make([]int, len(names))
+	lowLink := make([]int, len(names))
+	onStack := make([]bool, len(names))
+	componentOf := make([]int, len(names))
+	for i := range visitOrder {
+		visitOrder[i] = unvisited
+	}
+	var stack []int
+	var nextVisit int
+
+	// A frame is a node part way through examining its edges.
+	type frame struct {
+		node     int
+		nextEdge int
+	}
+	for root := range names {
+		if visitOrder[root] != unvisited {
+			continue
+		}
+		callStack := []frame{{node: root}}
+		visitOrder[root], lowLink[root] = nextVisit, nextVisit
+		nextVisit++
+		stack = append(stack, root)
+		onStack[root] = true
+
+		for len(callStack) > 0 {
+			top := &callStack[len(callStack)-1]
+			node := top.node
+
+			// Follow the next edge.
+			if top.nextEdge < len(edges[node]) {
+				next := edges[node][top.nextEdge]
+				top.nextEdge++
+				if visitOrder[next] == unvisited {
+					visitOrder[next], lowLink[next] = nextVisit, nextVisit
+					nextVisit++
+					stack = append(stack, next)
+					onStack[next] = true
+					callStack = append(callStack, frame{node: next})
+				} else if onStack[next] && visitOrder[next] < lowLink[node] {
+					lowLink[node] = visitOrder[next]
+				}
+				continue
+			}
+
+			// All edges followed. The root of a component gathers everything above it on the stack.
+			if lowLink[node] == visitOrder[node] {
+				component := Component{Index: len(condensation.Components), DependsOn: map[int]bool{}}
+				for {
+					member := stack[len(stack)-1]
+					stack = stack[:len(stack)-1]
+					onStack[member] = false
+					componentOf[member] = component.Index
+					component.Names = append(component.Names, names[member])
+					if member == node {
+						break
+					}
+				}
+				sort.Strings(component.Names)
+				condensation.Components = append(condensation.Components, component)
+			}
+
+			// Return to the caller.
+			callStack = callStack[:len(callStack)-1]
+			if len(callStack) > 0 {
+				caller := callStack[len(callStack)-1].node
+				if lowLink[node] < lowLink[caller] {
+					lowLink[caller] = lowLink[node]
+				}
+			}
+		}
+	}
+
+	// Wire the components together.
+	condensation.ComponentOf = make(map[string]int, len(names))
+	for i, name := range names {
+		condensation.ComponentOf[name] = componentOf[i]
+	}
+	for i, name := range names {
+		from := componentOf[i]
+		for _, j := range edges[i] {
+			to := componentOf[j]
+			if from == to {
+				condensation.Components[from].InternalEdges = append(condensation.Components[from].InternalEdges, Edge{From: name, To: names[j]})
+			} else {
+				condensation.Components[from].DependsOn[to] = true
+			}
+		}
+	}
+
+	return condensation
+}
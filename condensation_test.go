@@ -0,0 +1,55 @@
+package technical_debt
+
+import (
+	. "gopkg.in/check.v1" // https://labix.org/gocheck
+)
+
+// Create a suite.
+type CondensationSuite struct{}
+
+var _ = Suite(&CondensationSuite{})
+
+// Add the tests.
+
+func (s *CondensationSuite) Test_FindCondensation(c *C) {
+	codeFiles := testCodeFiles(map[string][]string{
+		"a": {"b"},
+		"b": {"c"},
+		"c": {"b", "d"},
+		"d": {},
+		"e": {"e"}, // Depending on itself is not a cycle.
+	})
+
+	condensation := FindCondensation(codeFiles)
+	c.Assert(condensation, DeepEquals, Condensation{
+		Components: []Component{
+			{Index: 0, Names: []string{"d"}, DependsOn: map[int]bool{}},
+			{Index: 1, Names: []string{"b", "c"}, InternalEdges: []Edge{{From: "b", To: "c"}, {From: "c", To: "b"}}, DependsOn: map[int]bool{0: true}},
+			{Index: 2, Names: []string{"a"}, DependsOn: map[int]bool{1: true}},
+			{Index: 3, Names: []string{"e"}, DependsOn: map[int]bool{}},
+		},
+		ComponentOf: map[string]int{"a": 2, "b": 1, "c": 1, "d": 0, "e": 3},
+	})
+
+	// The deep dependencies follow the condensation.
+	closure := CalculateDeepCodeFiles(codeFiles, condensation)
+	c.Check(closure.Reached("a"), DeepEquals, []string{"a", "b", "c", "d"})
+	c.Check(closure.Reached("b"), DeepEquals, []string{"b", "c", "d"})
+	c.Check(closure.Reached("c"), DeepEquals, []string{"b", "c", "d"})
+	c.Check(closure.Reached("d"), DeepEquals, []string{"d"})
+	c.Check(closure.Reached("e"), DeepEquals, []string{"e"})
+	c.Check(closure.VisibilityFanIn("d"), Equals, 4)
+
+	// Each component is a cyclical group.
+	_, err := CalculateMetrics(codeFiles, closure)
+	c.Assert(err, IsNil)
+	AddCyclicFingerPrints(codeFiles, condensation)
+	groups, coreCount, fileCount := CreateCyclicalGroups(codeFiles, condensation)
+	c.Check(len(groups), Equals, 4)
+	c.Check(coreCount, Equals, 2)
+	c.Check(fileCount, Equals, 5)
+	for _, group := range groups {
+		c.Check(group.InternalEdges, DeepEquals, condensation.Components[group.Component].InternalEdges)
+		c.Check(group.FileCount, Equals, len(condensation.Components[group.Component].Names))
+	}
+}
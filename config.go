@@ -3,15 +3,35 @@ package technical_debt
 import (
 	"encoding/json"
 	"io/ioutil"
+	"strings"
 )
 
 // Config is the information we need to run.
 type Config struct {
-	Gopath       string
-	RootPath     string
-	Paths        []string
+	Gopath       string   // The GOPATH source root, for projects that do not use modules.
+	ModuleRoot   string   // The directory with the go.work or go.mod of the project.
+	RootPath     string   // The technical debt root with templates and output.
+	Paths        []string // The import path prefixes to analyze. Optional with modules.
 	View         string
-	IncludeTests bool
+	Tests        string   // How test files are analyzed, exclude (the default), include or overlay.
+	IncludeTests bool     // Deprecated: the same as Tests 'include'.
+	Resolver     string   // How references are matched to declarations, syntax (the default, over-approximating) or types.
+	Granularity  string   // What each node of the graph is, file (the default), declaration or package.
+	PackageView  bool     // Also analyze the files collapsed into their packages.
+	Concurrency  int      // How many files to parse at once, the number of CPUs if zero.
+	ParseErrors  string   // What to do with files that have errors, abort (the default), exclude or partial.
+	GOOS         string   // The target operating system for build constraints, the current one if blank.
+	GOARCH       string   // The target architecture for build constraints, the current one if blank.
+	BuildTags    []string // The extra build tags that are satisfied.
+	AllPlatforms bool     // Analyze files for every platform together instead of obeying build constraints.
+	Include      []string // Glob patterns of the files to analyze, relative to the module. Every file if empty.
+	Exclude      []string // Glob patterns of the files and directories to leave out, relative to the module.
+	Generated    string   // What to do with generated files, include (the default), exclude or mark.
+	Distances    bool     // Also measure how far apart dependencies are, which is slower for large projects.
+	Decay        *float64 // The weight kept with each hop for the decayed propogation cost, 0.5 if missing.
+	Breakdown    bool     // Also calculate the metrics of every package and directory subtree.
+	Clusters     bool     // Cluster the nodes of each package together in the dot and graphml graphs.
+	Outputs      []string // What to write into the output folder, any of grid, json, csv, tsv, dot, graphml and html, just the grid if empty.
 }
 
 // LoadConfig loads a json config.
@@ -38,14 +58,17 @@ func LoadConfig(filename string) (config Config, err error) {
 
 // validate confirms a well-formed config.
 func (c Config) validate() (err error) {
-	if c.Gopath == "" {
-		return Errorf(`config requires Gopath`)
+	if c.Gopath == "" && c.ModuleRoot == "" {
+		return Errorf(`config requires Gopath or ModuleRoot`)
+	}
+	if c.Gopath != "" && c.ModuleRoot != "" {
+		return Errorf(`config cannot have both Gopath and ModuleRoot`)
 	}
 	if c.RootPath == "" {
 		return Errorf(`config requires RootPath`)
 	}
-	if len(c.Paths) == 0 {
-		return Errorf(`config requires Paths`)
+	if c.Gopath != "" && len(c.Paths) == 0 {
+		return Errorf(`config requires Paths with Gopath`)
 	}
 	for _, path := range c.Paths {
 		if path == "" {
@@ -55,6 +78,121 @@ func (c Config) validate() (err error) {
 	if !(c.View == VIEW_CORE_PERIPHERY || c.View == VIEW_MEDIAN) {
 		return Errorf(`config View must be either '%s' or '%s'`, VIEW_CORE_PERIPHERY, VIEW_MEDIAN)
 	}
+	if !(c.Tests == "" || c.Tests == TESTS_EXCLUDE || c.Tests == TESTS_INCLUDE || c.Tests == TESTS_OVERLAY) {
+		return Errorf(`config Tests must be one of '%s', '%s' or '%s'`, TESTS_EXCLUDE, TESTS_INCLUDE, TESTS_OVERLAY)
+	}
+	if c.IncludeTests && c.Tests == TESTS_EXCLUDE {
+		return Errorf(`config IncludeTests cannot be combined with Tests '%s'`, TESTS_EXCLUDE)
+	}
+	if !(c.Resolver == "" || c.Resolver == RESOLVER_SYNTAX || c.Resolver == RESOLVER_TYPES) {
+		return Errorf(`config Resolver must be either '%s' or '%s'`, RESOLVER_SYNTAX, RESOLVER_TYPES)
+	}
+	if !(c.Granularity == "" || c.Granularity == GRANULARITY_FILE || c.Granularity == GRANULARITY_DECLARATION || c.Granularity == GRANULARITY_PACKAGE) {
+		return Errorf(`config Granularity must be one of '%s', '%s' or '%s'`, GRANULARITY_FILE, GRANULARITY_DECLARATION, GRANULARITY_PACKAGE)
+	}
+	if !(c.ParseErrors == "" || c.ParseErrors == PARSE_ERRORS_ABORT || c.ParseErrors == PARSE_ERRORS_EXCLUDE || c.ParseErrors == PARSE_ERRORS_PARTIAL) {
+		return Errorf(`config ParseErrors must be one of '%s', '%s' or '%s'`, PARSE_ERRORS_ABORT, PARSE_ERRORS_EXCLUDE, PARSE_ERRORS_PARTIAL)
+	}
+	if c.AllPlatforms && (c.GOOS != "" || c.GOARCH != "" || len(c.BuildTags) > 0) {
+		return Errorf(`config AllPlatforms cannot be combined with GOOS, GOARCH or BuildTags`)
+	}
+	for _, patterns := range [][]string{c.Include, c.Exclude} {
+		for _, pattern := range patterns {
+			if !validGlob(pattern) {
+				return Errorf(`config has malformed glob pattern '%s'`, pattern)
+			}
+		}
+	}
+	if !(c.Generated == "" || c.Generated == GENERATED_INCLUDE || c.Generated == GENERATED_EXCLUDE || c.Generated == GENERATED_MARK) {
+		return Errorf(`config Generated must be one of '%s', '%s' or '%s'`, GENERATED_INCLUDE, GENERATED_EXCLUDE, GENERATED_MARK)
+	}
+	if c.Decay != nil && (*c.Decay < 0 || *c.Decay > 1) {
+		return Errorf(`config Decay must be between 0 and 1`)
+	}
+	for _, output := range c.Outputs {
+		if !validOutput(output) {
+			return Errorf(`config Outputs must each be one of '%s', '%s', '%s', '%s', '%s', '%s' or '%s'`, OUTPUT_GRID, OUTPUT_JSON, OUTPUT_CSV, OUTPUT_TSV, OUTPUT_DOT, OUTPUT_GRAPHML, OUTPUT_HTML)
+		}
+	}
+	if c.Concurrency < 0 {
+		return Errorf(`config Concurrency cannot be negative`)
+	}
+	if c.PackageView && c.Granularity == GRANULARITY_PACKAGE {
+		return Errorf(`config PackageView is already the view with Granularity '%s'`, GRANULARITY_PACKAGE)
+	}
 
 	return nil
 }
+
+// DistanceDecay gets the weight kept with each hop for the decayed propogation cost.
+func (c Config) DistanceDecay() float64 {
+	if c.Decay == nil {
+		return DEFAULT_DECAY
+	}
+	return *c.Decay
+}
+
+// ParseOutputs gets the outputs from a comma separated list, like the command line takes.
+func ParseOutputs(list string) (outputs []string, err error) {
+	for _, output := range strings.Split(list, ",") {
+		output = strings.TrimSpace(output)
+		if !validOutput(output) {
+			return nil, Errorf(`output '%s' must be one of '%s', '%s', '%s', '%s', '%s', '%s' or '%s'`, output, OUTPUT_GRID, OUTPUT_JSON, OUTPUT_CSV, OUTPUT_TSV, OUTPUT_DOT, OUTPUT_GRAPHML, OUTPUT_HTML)
+		}
+		outputs = append(outputs, output)
+	}
+	return outputs, nil
+}
+
+// validOutput reports whether an output is one that can be written.
+func validOutput(output string) bool {
+	switch output {
+	case OUTPUT_GRID, OUTPUT_JSON, OUTPUT_CSV, OUTPUT_TSV, OUTPUT_DOT, OUTPUT_GRAPHML, OUTPUT_HTML:
+		return true
+	}
+	return false
+}
+
+// Writes reports whether an output is written into the output folder.
+func (c Config) Writes(output string) bool {
+	if len(c.Outputs) == 0 {
+		return output == OUTPUT_GRID
+	}
+	for _, o := range c.Outputs {
+		if o == output {
+			return true
+		}
+	}
+	return false
+}
+
+// Modules gets the modules that make up the project.
+func (c Config) Modules() (modules []Module, err error) {
+
+	// Without modules the whole GOPATH is treated as a single body of code.
+	if c.Gopath != "" {
+		return []Module{{Dir: c.Gopath}}, nil
+	}
+
+	if modules, err = LoadModules(c.ModuleRoot); err != nil {
+		return nil, Error(err)
+	}
+
+	return modules, nil
+}
+
+// ProjectPaths gets the import path prefixes of packages that are part of the project.
+func (c Config) ProjectPaths(modules []Module) (projectPaths []string) {
+
+	// Without modules the only way to know the project is the hand-maintained paths.
+	if c.Gopath != "" {
+		return c.Paths
+	}
+
+	// Every package in a module is part of the project.
+	for _, module := range modules {
+		projectPaths = append(projectPaths, module.Path)
+	}
+
+	return projectPaths
+}
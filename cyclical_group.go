@@ -1,47 +1,34 @@
 package technical_debt
 
 import (
-	"crypto/md5"
-	"fmt"
-	"io"
 	"sort"
-	"strings"
 )
 
-// CyclicalGroup is a distince set of files with the same dependencies.
+// CyclicalGroup is a distince set of files with the same dependencies, a strongly connected component.
 type CyclicalGroup struct {
 	FileCount         int
 	VisibilityFanIn   int
 	VisibilityFanOut  int
-	CyclicFingerprint string     // The md5 fingerprint of the dependencies.
+	CyclicFingerprint string     // Identifies the group, the first name of its component.
+	Component         int        // The index of the group in the condensation.
+	InternalEdges     []Edge     // The direct dependencies between files of the group.
 	Files             []CodeFile // The sorted code files in this group.
 }
 
-// AddCyclicFingerPrints computes a fingerprint for each dependency set.
-func AddCyclicFingerPrints(codeFiles map[string]CodeFile) {
+// AddCyclicFingerPrints identifies the cyclical group of each code file from its component.
+func AddCyclicFingerPrints(codeFiles map[string]CodeFile, condensation Condensation) {
 	for filename := range codeFiles {
 		codeFile := codeFiles[filename]
 
-		// Start with filenames.
-		var dependsOnFilenames []string
-		for dependsOnFilename := range codeFile.DependsOn {
-			dependsOnFilenames = append(dependsOnFilenames, dependsOnFilename)
-		}
-		sort.Strings(dependsOnFilenames)
-
-		// Make an md5 of the information.
-		h := md5.New()
-		io.WriteString(h, strings.Join(dependsOnFilenames, ";"))
-		md5 := fmt.Sprintf("%x", h.Sum(nil))
-
-		// Remember it.
-		codeFile.CyclicFingerprint = md5
+		// Every file of a component shares both the fingerprint and the index.
+		codeFile.Component = condensation.ComponentOf[filename]
+		codeFile.CyclicFingerprint = condensation.Components[codeFile.Component].Names[0]
 		codeFiles[filename] = codeFile
 	}
 }
 
 // CreateCyclicalGroups gathers code files into their cyclical groups.
-func CreateCyclicalGroups(fileLookup map[string]CodeFile) (groups []CyclicalGroup, coreCount, fileCount int) {
+func CreateCyclicalGroups(fileLookup map[string]CodeFile, condensation Condensation) (groups []CyclicalGroup, coreCount, fileCount int) {
 
 	// Sort code files.
 	fileCount = len(fileLookup)
@@ -62,6 +49,8 @@ func CreateCyclicalGroups(fileLookup map[string]CodeFile) (groups []CyclicalGrou
 				VisibilityFanIn:   codeFile.VisibilityFanIn,
 				VisibilityFanOut:  codeFile.VisibilityFanOut,
 				CyclicFingerprint: codeFile.CyclicFingerprint,
+				Component:         codeFile.Component,
+				InternalEdges:     condensation.Components[codeFile.Component].InternalEdges,
 				Files:             []CodeFile{codeFile},
 			}
 		} else {
@@ -0,0 +1,89 @@
+package technical_debt
+
+import (
+	"go/ast"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+const (
+	GRANULARITY_FILE        = "file"
+	GRANULARITY_DECLARATION = "declaration"
+	GRANULARITY_PACKAGE     = "package"
+)
+
+// declarationRange is the source covered by a single top level declaration.
+type declarationRange struct {
+	pos   token.Pos // The start of the declaration.
+	end   token.Pos // Just past the end of the declaration.
+	name  string    // The name of the declaration, "Type.Method" for methods.
+	lines int       // The lines of code of the declaration.
+}
+
+// declarationRanges finds the top level declarations of a file in source order.
+func declarationRanges(parsedFile *ast.File) (ranges []declarationRange) {
+
+	for _, decl := range parsedFile.Decls {
+		switch typed := decl.(type) {
+
+		case *ast.FuncDecl:
+			name := typed.Name.Name
+			if typed.Recv != nil && len(typed.Recv.List) > 0 {
+				name = receiverTypeName(typed.Recv.List[0].Type) + "." + name
+			}
+			ranges = append(ranges, declarationRange{pos: typed.Pos(), end: typed.End(), name: name})
+
+		case *ast.GenDecl:
+			// Each spec in a grouped declaration stands alone.
+			for _, spec := range typed.Specs {
+				switch typedSpec := spec.(type) {
+				case *ast.TypeSpec:
+					ranges = append(ranges, declarationRange{pos: typedSpec.Pos(), end: typedSpec.End(), name: typedSpec.Name.Name})
+				case *ast.ValueSpec:
+					// Names declared together share their values so they are a single declaration.
+					var names []string
+					for _, name := range typedSpec.Names {
+						names = append(names, name.Name)
+					}
+					ranges = append(ranges, declarationRange{pos: typedSpec.Pos(), end: typedSpec.End(), name: strings.Join(names, ",")})
+				}
+			}
+		}
+	}
+
+	// Declarations are already in source order, but be certain since searches depend on it.
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].pos < ranges[j].pos })
+
+	return ranges
+}
+
+// enclosingDeclaration finds the name of the declaration containing a position, blank if there is none.
+func enclosingDeclaration(ranges []declarationRange, pos token.Pos) (name string) {
+
+	// Find the first declaration ending after the position.
+	i := sort.Search(len(ranges), func(i int) bool { return ranges[i].end > pos })
+	if i < len(ranges) && ranges[i].pos <= pos {
+		return ranges[i].name
+	}
+
+	return ""
+}
+
+// declarationLocation is where a declaration is found.
+type declarationLocation struct {
+	importPath  string // The package of the declaration.
+	file        string // The name of the file of the declaration, without a path.
+	declaration string // The name of the declaration.
+}
+
+// nodeName gets the name of the graph node the declaration is part of.
+func (l declarationLocation) nodeName(granularity string) (name string) {
+	switch granularity {
+	case GRANULARITY_PACKAGE:
+		return l.importPath
+	case GRANULARITY_DECLARATION:
+		return l.importPath + "/" + l.file + ":" + l.declaration
+	}
+	return l.importPath + "/" + l.file
+}
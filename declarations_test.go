@@ -0,0 +1,115 @@
+package technical_debt
+
+import (
+	. "gopkg.in/check.v1" // https://labix.org/gocheck
+
+	"go/parser"
+	"go/token"
+	"path/filepath"
+)
+
+// Create a suite.
+type DeclarationsSuite struct{}
+
+var _ = Suite(&DeclarationsSuite{})
+
+// Add the tests.
+
+func (s *DeclarationsSuite) Test_EnclosingDeclaration(c *C) {
+	source := `package p
+
+import "fmt"
+
+var a, b = 1, 2
+
+type (
+	T struct{}
+	U int
+)
+
+func (t *T) Do() { fmt.Println(a) }
+`
+	fset := token.NewFileSet()
+	parsedFile, err := parser.ParseFile(fset, "p.go", source, 0)
+	c.Assert(err, IsNil)
+	ranges := declarationRanges(parsedFile)
+
+	var names []string
+	for _, declarationRange := range ranges {
+		names = append(names, declarationRange.name)
+	}
+	c.Assert(names, DeepEquals, []string{"a,b", "T", "U", "T.Do"})
+
+	// Find the declaration at positions in the source.
+	file := fset.File(parsedFile.Pos())
+	tests := []struct {
+		offset int
+		name   string
+	}{
+		{offset: 0, name: ""},  // The package clause.
+		{offset: 12, name: ""}, // The import.
+		{offset: 32, name: "a,b"},
+		{offset: 50, name: "T"},
+		{offset: 62, name: "U"},
+		{offset: 94, name: "T.Do"},
+	}
+	for i, test := range tests {
+		comment := Commentf("Case %v: %v", i, test)
+		c.Check(enclosingDeclaration(ranges, file.Pos(test.offset)), Equals, test.name, comment)
+	}
+}
+
+func (s *DeclarationsSuite) Test_Granularity(c *C) {
+	tests := []struct {
+		moduleRoot  string
+		granularity string
+		codeFile    string
+		dependsOn   []string
+	}{
+		{
+			moduleRoot:  "testdata/methods",
+			granularity: GRANULARITY_DECLARATION,
+			codeFile:    "example.com/methods/things/use.go:Use",
+			dependsOn: []string{
+				"example.com/methods/things/list.go:List",
+				"example.com/methods/things/list_push.go:List.Push",
+				"example.com/methods/things/thing.go:Thing",
+				"example.com/methods/things/thing_do.go:Thing.Do",
+			},
+		},
+		{
+			moduleRoot:  "testdata/resolve",
+			granularity: GRANULARITY_PACKAGE,
+			codeFile:    "example.com/resolve/app",
+			dependsOn:   []string{"example.com/resolve/shapes"},
+		},
+		{
+			moduleRoot:  "testdata/resolve",
+			granularity: GRANULARITY_PACKAGE,
+			codeFile:    "example.com/resolve/shapes",
+			dependsOn:   nil,
+		},
+	}
+	for i, test := range tests {
+		for resolver, codeFiles := range resolverCodeFiles(c, test.moduleRoot, Config{Granularity: test.granularity}) {
+			comment := Commentf("Case %v: %v Resolver: %v", i, test, resolver)
+			c.Check(dependsOnNames(codeFiles[test.codeFile]), DeepEquals, test.dependsOn, comment)
+		}
+	}
+}
+
+func (s *DeclarationsSuite) Test_SameFileDeclarations(c *C) {
+	root := c.MkDir()
+	writeTestFile(c, filepath.Join(root, "go.mod"), "module example.com/p\n")
+	writeTestFile(c, filepath.Join(root, "p.go"), "package p\n\nfunc A() { B() }\n\nfunc B() { A() }\n\nfunc C(A int) int { return A }\n")
+
+	// The parser resolves names declared in the same file, which are still dependencies between
+	// declarations. A parameter shadowing a declaration is not one.
+	tests := []dependsOnTest{
+		{codeFile: "example.com/p/p.go:A", dependsOn: []string{"example.com/p/p.go:B"}},
+		{codeFile: "example.com/p/p.go:B", dependsOn: []string{"example.com/p/p.go:A"}},
+		{codeFile: "example.com/p/p.go:C", dependsOn: nil},
+	}
+	byResolver := resolverCodeFiles(c, root, Config{Granularity: GRANULARITY_DECLARATION})
+	checkDependsOn(c, byResolver, tests)
+}
@@ -0,0 +1,103 @@
+package technical_debt
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	DEFAULT_DECAY = 0.5 // Each hop beyond a direct dependency halves its weight.
+)
+
+// Distances is how far apart code files are along their shortest dependency paths.
+type Distances struct {
+	Histogram              []int   // How many dependencies are each distance long. Index zero is each code file on itself.
+	MeanDistance           float64 // The mean distance of the dependencies between different code files.
+	MaxDistance            int     // The longest distance of any dependency.
+	Decay                  float64 // The weight kept with each hop beyond a direct dependency.
+	DecayedPropogationCost float64 // The propogation cost with each dependency weighed by the decay for its distance.
+}
+
+// CalculateDistances finds the shortest distance of every deep dependency, with a breadth first search
+// from every code file along the direct dependencies. The closure of the analysis already knows how many
+// code files each search can reach, so a search stops once it has found them all and code files reaching
+// only themselves are never searched. A dependency of distance d is weighed by decay to the power d - 1.
+// With a decay of one the decayed propogation cost is the propogation cost.
+func CalculateDistances(analysis Analysis, decay float64) (distances Distances) {
+	distances.Decay = decay
+	codeFiles := analysis.CodeFiles
+
+	// No files have no distances.
+	if len(codeFiles) == 0 {
+		return distances
+	}
+
+	// Number the nodes in name order so the results do not depend on map ordering.
+	var names []string
+	for name := range codeFiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	nodeIndexes := make(map[string]int, len(names))
+	for i, name := range names {
+		nodeIndexes[name] = i
+	}
+	edges := make([][]int, len(names))
+	for i, name := range names {
+		for dependsOnName := range codeFiles[name].DependsOn {
+			if j, ok := nodeIndexes[dependsOnName]; ok && j != i {
+				edges[i] = append(edges[i], j)
+			}
+		}
+	}
+
+	// The same buffers are reused for every search.
+	const unvisited = -1
+	distance := make([]int, len(names))
+	for i := range distance {
+		distance[i] = unvisited
+	}
+	queue := make([]int, 0, len(names))
+	var weighed float64
+	var distanceTotal, dependencyCount int
+	for root, name := range names {
+		queue = append(queue[:0], root)
+		distance[root] = 0
+		reachable := analysis.Closure.VisibilityFanOut(name)
+		for head := 0; head < len(queue) && len(queue) < reachable; head++ {
+			node := queue[head]
+			for _, next := range edges[node] {
+				if distance[next] == unvisited {
+					distance[next] = distance[node] + 1
+					queue = append(queue, next)
+				}
+			}
+		}
+
+		// Every node reached was queued exactly once.
+		for _, node := range queue {
+			d := distance[node]
+			for len(distances.Histogram) <= d {
+				distances.Histogram = append(distances.Histogram, 0)
+			}
+			distances.Histogram[d]++
+			if d > 0 {
+				weighed += math.Pow(decay, float64(d-1))
+				distanceTotal += d
+				dependencyCount++
+			} else {
+				// Every file depends on itself, just like the propogation cost.
+				weighed++
+			}
+			distance[node] = unvisited
+		}
+	}
+
+	distances.MaxDistance = len(distances.Histogram) - 1
+	if dependencyCount > 0 {
+		distances.MeanDistance = float64(distanceTotal) / float64(dependencyCount)
+	}
+	distances.DecayedPropogationCost = weighed / float64(len(names)*len(names))
+
+	return distances
+}
@@ -0,0 +1,98 @@
+package technical_debt
+
+import (
+	. "gopkg.in/check.v1" // https://labix.org/gocheck
+
+	"math"
+)
+
+// Create a suite.
+type DistancesSuite struct{}
+
+var _ = Suite(&DistancesSuite{})
+
+// Add the tests.
+
+func (s *DistancesSuite) Test_Chain(c *C) {
+	codeFiles := testCodeFiles(map[string][]string{
+		"a": {"b"},
+		"b": {"c"},
+		"c": {"d"},
+		"d": {},
+	})
+	analysis, err := Analyze(codeFiles, VIEW_MEDIAN)
+	c.Assert(err, IsNil)
+
+	distances := CalculateDistances(analysis, 0.5)
+	c.Check(distances.Histogram, DeepEquals, []int{4, 3, 2, 1})
+	c.Check(distances.MaxDistance, Equals, 3)
+	c.Check(distances.MeanDistance, Equals, 10.0/6.0)
+	c.Check(distances.Decay, Equals, 0.5)
+	c.Check(distances.DecayedPropogationCost, Equals, (4+3+2*0.5+1*0.25)/16.0)
+
+	c.Check(CalculateDistances(Analysis{}, 0.5), DeepEquals, Distances{Decay: 0.5})
+}
+
+func (s *DistancesSuite) Test_ShortestPaths(c *C) {
+	// The cycle is no longer than its shortest way around.
+	codeFiles := testCodeFiles(map[string][]string{
+		"a": {"b", "c"},
+		"b": {"c"},
+		"c": {"a"},
+	})
+	analysis, err := Analyze(codeFiles, VIEW_MEDIAN)
+	c.Assert(err, IsNil)
+
+	distances := CalculateDistances(analysis, 0.5)
+	c.Check(distances.Histogram, DeepEquals, []int{3, 4, 2})
+}
+
+func (s *DistancesSuite) Test_NoDecayIsPropogationCost(c *C) {
+	codeFiles := randomCodeFiles(300, 3, 0.05)
+	analysis, err := Analyze(codeFiles, VIEW_MEDIAN)
+	c.Assert(err, IsNil)
+
+	distances := CalculateDistances(analysis, 1)
+	c.Check(math.Abs(distances.DecayedPropogationCost-analysis.PropogationCost) < 1e-12, Equals, true)
+
+	// Every deep dependency has a distance.
+	var total int
+	for _, count := range distances.Histogram {
+		total += count
+	}
+	c.Check(total, Equals, int(math.Round(analysis.PropogationCost*300*300)))
+}
+
+func (s *DistancesSuite) Test_DistanceDecay(c *C) {
+	zero, half, tooMuch := 0.0, 0.5, 1.5
+	tests := []struct {
+		decay    *float64
+		expected float64
+		errorMsg string
+	}{
+		{decay: nil, expected: DEFAULT_DECAY},
+		{decay: &zero, expected: 0}, // Only direct dependencies count.
+		{decay: &half, expected: 0.5},
+		{decay: &tooMuch, errorMsg: `config Decay must be between 0 and 1`},
+	}
+	for i, test := range tests {
+		comment := Commentf("Case %v: %v", i, test)
+		config := Config{ModuleRoot: "testdata/methods", RootPath: "root", View: VIEW_MEDIAN, Decay: test.decay}
+		if test.errorMsg != "" {
+			c.Check(ErrorMessage(config.validate()), Equals, test.errorMsg, comment)
+			continue
+		}
+		c.Check(config.validate(), IsNil, comment)
+		c.Check(config.DistanceDecay(), Equals, test.expected, comment)
+	}
+
+	// Without decay the decayed propogation cost is every code file on itself and the direct dependencies.
+	codeFiles := testCodeFiles(map[string][]string{
+		"a": {"b"},
+		"b": {"c"},
+		"c": {},
+	})
+	analysis, err := Analyze(codeFiles, VIEW_MEDIAN)
+	c.Assert(err, IsNil)
+	c.Check(CalculateDistances(analysis, 0).DecayedPropogationCost, Equals, (3+2)/9.0)
+}
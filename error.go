@@ -1,7 +1,10 @@
 package technical_debt
 
 import (
+	"errors"
 	"fmt"
+	"go/scanner"
+	"go/token"
 	"runtime/debug"
 )
 
@@ -9,6 +12,7 @@ import (
 type errorWithStack struct {
 	message   string // The message of the error.
 	stackDump string // The stack the moment of creation.
+	err       error  // The original error.
 }
 
 // Error prints the whole error.
@@ -16,6 +20,11 @@ func (e *errorWithStack) Error() string {
 	return e.message + "\n\n" + e.stackDump
 }
 
+// Unwrap gives the original error, so typed errors can still be found with errors.As.
+func (e *errorWithStack) Unwrap() error {
+	return e.err
+}
+
 // Error creates an new error with stack information.
 func Error(err error) (errWithStack error) {
 
@@ -34,6 +43,7 @@ func Error(err error) (errWithStack error) {
 	return &errorWithStack{
 		message:   err.Error(),
 		stackDump: string(debug.Stack()),
+		err:       err,
 	}
 }
 
@@ -41,3 +51,85 @@ func Error(err error) (errWithStack error) {
 func Errorf(template string, params ...interface{}) (err error) {
 	return Error(fmt.Errorf(template, params...))
 }
+
+// ErrorMessage gets the message of an error without any stack information.
+func ErrorMessage(err error) (message string) {
+	var withStack *errorWithStack
+	if errors.As(err, &withStack) {
+		return withStack.message
+	}
+	return err.Error()
+}
+
+// SourceError is a problem found at a position in a source file.
+type SourceError struct {
+	Filename string
+	Line     int
+	Column   int
+	Message  string
+}
+
+// Error prints the position and the problem.
+func (e *SourceError) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %s", e.Filename, e.Line, e.Column, e.Message)
+}
+
+// newSourceError creates a source error at a position.
+func newSourceError(fset *token.FileSet, pos token.Pos, message string) (err *SourceError) {
+	position := fset.Position(pos)
+	return &SourceError{
+		Filename: position.Filename,
+		Line:     position.Line,
+		Column:   position.Column,
+		Message:  message,
+	}
+}
+
+// parseSourceError converts the errors of the parser into a source error for the first problem.
+func parseSourceError(err error) (sourceErr error) {
+	var errorList scanner.ErrorList
+	if !errors.As(err, &errorList) || len(errorList) == 0 {
+		return err
+	}
+	message := errorList[0].Msg
+	if len(errorList) > 1 {
+		message = fmt.Sprintf("%s (and %d more errors)", message, len(errorList)-1)
+	}
+	return &SourceError{
+		Filename: errorList[0].Pos.Filename,
+		Line:     errorList[0].Pos.Line,
+		Column:   errorList[0].Pos.Column,
+		Message:  message,
+	}
+}
+
+// parseDiagnostics converts every error of the parser into a source error.
+func parseDiagnostics(err error) (diagnostics []SourceError) {
+	var errorList scanner.ErrorList
+	if !errors.As(err, &errorList) {
+		return []SourceError{{Message: err.Error()}}
+	}
+	for _, listed := range errorList {
+		diagnostics = append(diagnostics, SourceError{
+			Filename: listed.Pos.Filename,
+			Line:     listed.Pos.Line,
+			Column:   listed.Pos.Column,
+			Message:  listed.Msg,
+		})
+	}
+	return diagnostics
+}
+
+// GraphError is a problem found in the dependency graph.
+type GraphError struct {
+	Name    string // The code file with the problem, blank if it is with the graph as a whole.
+	Message string
+}
+
+// Error prints the code file and the problem.
+func (e *GraphError) Error() string {
+	if e.Name == "" {
+		return e.Message
+	}
+	return e.Name + ": " + e.Message
+}
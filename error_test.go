@@ -0,0 +1,57 @@
+package technical_debt
+
+import (
+	. "gopkg.in/check.v1" // https://labix.org/gocheck
+
+	"errors"
+	"path/filepath"
+)
+
+// Create a suite.
+type ErrorSuite struct{}
+
+var _ = Suite(&ErrorSuite{})
+
+// Add the tests.
+
+func (s *ErrorSuite) Test_TypedErrorsSurviveStacks(c *C) {
+	err := Error(Error(&GraphError{Name: "a.go", Message: "broken"}))
+	c.Check(err, ErrorMatches, "a.go: broken\n\n(?s).*")
+	c.Check(ErrorMessage(err), Equals, "a.go: broken")
+
+	var graphErr *GraphError
+	c.Assert(errors.As(err, &graphErr), Equals, true)
+	c.Check(graphErr.Name, Equals, "a.go")
+
+	// Errors without stacks pass through.
+	c.Check(ErrorMessage(errors.New("plain")), Equals, "plain")
+}
+
+func (s *ErrorSuite) Test_MetricsMismatch(c *C) {
+	codeFiles := testCodeFiles(map[string][]string{"a": {"b"}, "b": {}})
+	closure := NewClosure(FindCondensation(codeFiles))
+	delete(codeFiles, "b") // The closure no longer matches the code files.
+
+	_, err := CalculateMetrics(codeFiles, closure)
+	var graphErr *GraphError
+	c.Assert(errors.As(err, &graphErr), Equals, true)
+	c.Check(graphErr.Message, Equals, "total fan in 1 and fan out 2 should be identical")
+}
+
+func (s *ErrorSuite) Test_ParseErrorPosition(c *C) {
+	root := c.MkDir()
+	writeTestFile(c, filepath.Join(root, "go.mod"), "module example.com/broken\n")
+	writeTestFile(c, filepath.Join(root, "broken.go"), "package broken\n\nfunc Broken() {\n")
+
+	modules, err := LoadModules(root)
+	c.Assert(err, IsNil)
+	packages, err := PackagePaths(modules, Config{})
+	c.Assert(err, IsNil)
+	_, _, err = ProcessPackage(Config{}, packages, []string{"example.com/broken"})
+
+	var sourceErr *SourceError
+	c.Assert(errors.As(err, &sourceErr), Equals, true)
+	c.Check(sourceErr.Filename, Equals, filepath.Join(root, "broken.go"))
+	c.Check(sourceErr.Line, Equals, 3)
+	c.Check(sourceErr.Message, Equals, "expected '}', found 'EOF'")
+}
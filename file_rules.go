@@ -0,0 +1,109 @@
+package technical_debt
+
+import (
+	"bufio"
+	"bytes"
+	"path"
+	"regexp"
+	"strings"
+)
+
+const (
+	GENERATED_INCLUDE = "include" // Generated files are analyzed like any other.
+	GENERATED_EXCLUDE = "exclude" // Generated files are left out.
+	GENERATED_MARK    = "mark"    // Generated files are analyzed but shown apart from written code.
+)
+
+// generatedPattern is the standard comment marking generated code, https://golang.org/s/generatedcode.
+var generatedPattern = regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`)
+
+// ignoredDir reports whether a directory is never part of a project, like the go tool does.
+func ignoredDir(name string) bool {
+	return strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "vendor" || name == "testdata"
+}
+
+// excludedPath reports whether a file or directory, relative to its module, is left out by the config.
+func (c Config) excludedPath(relativePath string) bool {
+	for _, pattern := range c.Exclude {
+		if matchGlob(pattern, relativePath) {
+			return true
+		}
+	}
+	return false
+}
+
+// includedFile reports whether a file, relative to its module, is analyzed according to the config.
+func (c Config) includedFile(relativePath string) bool {
+	if c.excludedPath(relativePath) {
+		return false
+	}
+	if len(c.Include) == 0 {
+		return true
+	}
+	for _, pattern := range c.Include {
+		if matchGlob(pattern, relativePath) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob reports whether a slash separated path matches a glob pattern. A "**" element matches any
+// number of directories, and a pattern without a slash matches the last element of the path anywhere.
+func matchGlob(pattern, name string) bool {
+	if !strings.Contains(pattern, "/") {
+		matched, _ := path.Match(pattern, path.Base(name))
+		return matched
+	}
+	return matchGlobElements(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+// matchGlobElements matches the elements of a path against the elements of a glob pattern.
+func matchGlobElements(patterns, elements []string) bool {
+	if len(patterns) == 0 {
+		return len(elements) == 0
+	}
+	if patterns[0] == "**" {
+		for i := 0; i <= len(elements); i++ {
+			if matchGlobElements(patterns[1:], elements[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(elements) == 0 {
+		return false
+	}
+	if matched, _ := path.Match(patterns[0], elements[0]); !matched {
+		return false
+	}
+	return matchGlobElements(patterns[1:], elements[1:])
+}
+
+// validGlob reports whether every element of a glob pattern is well-formed.
+func validGlob(pattern string) bool {
+	for _, element := range strings.Split(pattern, "/") {
+		if _, err := path.Match(element, ""); err != nil {
+			return false
+		}
+	}
+	return pattern != ""
+}
+
+// generatedFile reports whether a file has the standard comment marking generated code before
+// its package clause.
+func generatedFile(data []byte) bool {
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "package ") {
+			break
+		}
+		if generatedPattern.MatchString(line) {
+			return true
+		}
+	}
+
+	return false
+}
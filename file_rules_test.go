@@ -0,0 +1,133 @@
+package technical_debt
+
+import (
+	. "gopkg.in/check.v1" // https://labix.org/gocheck
+
+	"sort"
+)
+
+// Create a suite.
+type FileRulesSuite struct{}
+
+var _ = Suite(&FileRulesSuite{})
+
+// Add the tests.
+
+func (s *FileRulesSuite) Test_MatchGlob(c *C) {
+	tests := []struct {
+		pattern string
+		name    string
+		matches bool
+	}{
+		{pattern: "mocks", name: "mocks", matches: true},
+		{pattern: "mocks", name: "internal/mocks", matches: true},
+		{pattern: "*.pb.go", name: "api/v1/message.pb.go", matches: true},
+		{pattern: "*.pb.go", name: "api/v1/message.go", matches: false},
+		{pattern: "api/*.go", name: "api/message.go", matches: true},
+		{pattern: "api/*.go", name: "api/v1/message.go", matches: false},
+		{pattern: "api/**/*.go", name: "api/message.go", matches: true},
+		{pattern: "api/**/*.go", name: "api/v1/message.go", matches: true},
+		{pattern: "**/mock_*.go", name: "a/b/mock_store.go", matches: true},
+		{pattern: "**/mock_*.go", name: "a/b/store.go", matches: false},
+	}
+	for i, test := range tests {
+		comment := Commentf("Case %v: %v", i, test)
+		c.Check(matchGlob(test.pattern, test.name), Equals, test.matches, comment)
+	}
+
+	c.Check(validGlob("api/**/*.go"), Equals, true)
+	c.Check(validGlob("api/[a"), Equals, false)
+	c.Check(validGlob(""), Equals, false)
+}
+
+func (s *FileRulesSuite) Test_GeneratedFile(c *C) {
+	tests := []struct {
+		data      string
+		generated bool
+	}{
+		{data: "// Code generated by protoc-gen-go. DO NOT EDIT.\n\npackage pb\n", generated: true},
+		{data: "// Copyright.\n\n// Code generated by hand. DO NOT EDIT.\r\npackage pb\n", generated: true},
+		{data: "// Code generated by hand. Do not edit.\npackage pb\n", generated: false},
+		{data: "package pb\n\n// Code generated by protoc-gen-go. DO NOT EDIT.\n", generated: false},
+	}
+	for i, test := range tests {
+		comment := Commentf("Case %v: %v", i, test)
+		c.Check(generatedFile([]byte(test.data)), Equals, test.generated, comment)
+	}
+}
+
+func (s *FileRulesSuite) Test_Rules(c *C) {
+	tests := []struct {
+		config Config
+		names  []string
+	}{
+		// Vendored and test data directories are never analyzed.
+		{
+			config: Config{},
+			names: []string{
+				"example.com/rules/app/app.go",
+				"example.com/rules/app/kind.go",
+				"example.com/rules/app/kind_string.go",
+				"example.com/rules/mocks/mock.go",
+				"example.com/rules/pb/message.pb.go",
+			},
+		},
+		{
+			config: Config{Exclude: []string{"mocks", "*_string.go"}},
+			names: []string{
+				"example.com/rules/app/app.go",
+				"example.com/rules/app/kind.go",
+				"example.com/rules/pb/message.pb.go",
+			},
+		},
+		{
+			config: Config{Include: []string{"app/*.go", "mocks/**"}, Exclude: []string{"app/kind.go"}},
+			names: []string{
+				"example.com/rules/app/app.go",
+				"example.com/rules/app/kind_string.go",
+				"example.com/rules/mocks/mock.go",
+			},
+		},
+		{
+			config: Config{Generated: GENERATED_EXCLUDE},
+			names: []string{
+				"example.com/rules/app/app.go",
+				"example.com/rules/app/kind.go",
+				"example.com/rules/mocks/mock.go",
+			},
+		},
+	}
+	for i, test := range tests {
+		comment := Commentf("Case %v: %v", i, test)
+		codeFiles := fixtureCodeFiles(c, "testdata/rules", test.config)
+		var names []string
+		for name := range codeFiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		c.Check(names, DeepEquals, test.names, comment)
+	}
+}
+
+func (s *FileRulesSuite) Test_GeneratedMarked(c *C) {
+	codeFiles := fixtureCodeFiles(c, "testdata/rules", Config{Generated: GENERATED_MARK})
+	c.Check(codeFiles["example.com/rules/app/kind_string.go"].Generated, Equals, true)
+	c.Check(codeFiles["example.com/rules/pb/message.pb.go"].Generated, Equals, true)
+	c.Check(codeFiles["example.com/rules/app/app.go"].Generated, Equals, false)
+
+	// Generated files are still depended on.
+	c.Check(dependsOnNames(codeFiles["example.com/rules/app/app.go"]), DeepEquals, []string{
+		"example.com/rules/app/kind.go",
+		"example.com/rules/mocks/mock.go",
+		"example.com/rules/pb/message.pb.go",
+	})
+
+	// A package is generated only if all of its files are.
+	packages := CollapseToPackages(codeFiles)
+	c.Check(packages["example.com/rules/pb"].Generated, Equals, true)
+	c.Check(packages["example.com/rules/app"].Generated, Equals, false)
+
+	// Without marking, nothing is generated.
+	codeFiles = fixtureCodeFiles(c, "testdata/rules", Config{})
+	c.Check(codeFiles["example.com/rules/pb/message.pb.go"].Generated, Equals, false)
+}
@@ -0,0 +1,67 @@
+package technical_debt
+
+import (
+	. "gopkg.in/check.v1" // https://labix.org/gocheck
+
+	"sort"
+)
+
+// testCodeFiles creates code files with direct dependencies.
+func testCodeFiles(dependencies map[string][]string) (codeFiles map[string]CodeFile) {
+	codeFiles = map[string]CodeFile{}
+	for name, dependsOn := range dependencies {
+		codeFile := CodeFile{Name: name, DependsOn: map[string]bool{}, Weights: map[string]EdgeWeight{}}
+		for _, dependsOnName := range dependsOn {
+			codeFile.DependsOn[dependsOnName] = true
+		}
+		codeFiles[name] = codeFile
+	}
+	return codeFiles
+}
+
+// fixtureCodeFiles processes a module under testdata into code files.
+func fixtureCodeFiles(c *C, moduleRoot string, config Config) (codeFiles map[string]CodeFile) {
+	config.ModuleRoot = moduleRoot
+	modules, err := config.Modules()
+	c.Assert(err, IsNil)
+	packages, err := PackagePaths(modules, config)
+	c.Assert(err, IsNil)
+	folders, _, err := ProcessPackage(config, packages, config.ProjectPaths(modules))
+	c.Assert(err, IsNil)
+	return CreateCodeFiles(folders, config)
+}
+
+// resolverCodeFiles processes a module under testdata into code files with each resolver.
+func resolverCodeFiles(c *C, moduleRoot string, config Config) (byResolver map[string]map[string]CodeFile) {
+	byResolver = map[string]map[string]CodeFile{}
+	for _, resolver := range []string{RESOLVER_SYNTAX, RESOLVER_TYPES} {
+		config.Resolver = resolver
+		byResolver[resolver] = fixtureCodeFiles(c, moduleRoot, config)
+	}
+	return byResolver
+}
+
+// dependsOnNames lists the sorted names a code file depends on.
+func dependsOnNames(codeFile CodeFile) (names []string) {
+	for name := range codeFile.DependsOn {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// dependsOnTest is a code file and the sorted names it depends on.
+type dependsOnTest struct {
+	codeFile  string
+	dependsOn []string
+}
+
+// checkDependsOn checks what code files depend on, as processed with each resolver.
+func checkDependsOn(c *C, byResolver map[string]map[string]CodeFile, tests []dependsOnTest) {
+	for i, test := range tests {
+		for resolver, codeFiles := range byResolver {
+			comment := Commentf("Case %v: %v Resolver: %v", i, test, resolver)
+			c.Check(dependsOnNames(codeFiles[test.codeFile]), DeepEquals, test.dependsOn, comment)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package technical_debt
+
+import (
+	. "gopkg.in/check.v1" // https://labix.org/gocheck
+)
+
+// Create a suite.
+type GenericsSuite struct{}
+
+var _ = Suite(&GenericsSuite{})
+
+// Add the tests.
+
+func (s *GenericsSuite) Test_Generics(c *C) {
+	byResolver := resolverCodeFiles(c, "testdata/generics", Config{})
+
+	tests := []dependsOnTest{
+		{
+			// Instantiated generic types and functions, and the methods of generic types.
+			codeFile: "example.com/generics/app/app.go",
+			dependsOn: []string{
+				"example.com/generics/collections/filter.go",
+				"example.com/generics/collections/map.go",
+				"example.com/generics/collections/map_set.go",
+				"example.com/generics/collections/pair.go",
+				"example.com/generics/collections/sum.go",
+			},
+		},
+		{
+			codeFile:  "example.com/generics/app/chain.go",
+			dependsOn: []string{"example.com/generics/collections/filter.go"},
+		},
+		{
+			// Type parameters are not package level names, even when the package has one with the same name.
+			codeFile:  "example.com/generics/collections/map_set.go",
+			dependsOn: []string{"example.com/generics/collections/map.go"},
+		},
+		{
+			// Constraints in other files and other packages, including the terms of a union.
+			codeFile:  "example.com/generics/collections/largest.go",
+			dependsOn: []string{"example.com/generics/collections/ordered.go"},
+		},
+		{
+			codeFile:  "example.com/generics/collections/sum.go",
+			dependsOn: []string{"example.com/generics/constraints/number.go"},
+		},
+		{
+			codeFile: "example.com/generics/constraints/number.go",
+			dependsOn: []string{
+				"example.com/generics/constraints/float.go",
+				"example.com/generics/constraints/integer.go",
+			},
+		},
+	}
+	checkDependsOn(c, byResolver, tests)
+}
+
+func (s *GenericsSuite) Test_GenericDeclarations(c *C) {
+	for resolver, codeFiles := range resolverCodeFiles(c, "testdata/generics", Config{Granularity: GRANULARITY_DECLARATION}) {
+		comment := Commentf("Resolver: %v", resolver)
+
+		// Methods of generic types are named without their type parameters.
+		c.Check(dependsOnNames(codeFiles["example.com/generics/app/app.go:Run"]), DeepEquals, []string{
+			"example.com/generics/collections/filter.go:Filter",
+			"example.com/generics/collections/map.go:Map",
+			"example.com/generics/collections/map.go:NewMap",
+			"example.com/generics/collections/map_set.go:Map.Set",
+			"example.com/generics/collections/pair.go:Pair",
+			"example.com/generics/collections/sum.go:Sum",
+		}, comment)
+	}
+}
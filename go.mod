@@ -2,10 +2,11 @@ module github.com/glemzurg/technical_debt
 
 go 1.19
 
+require gopkg.in/check.v1 v1.0.0-20201130134442-10cb98267c6c
+
 require (
 	github.com/gopherjs/gopherjs v1.17.2 // indirect
 	github.com/kr/pretty v0.2.1 // indirect
 	github.com/kr/text v0.1.0 // indirect
 	github.com/mailgun/godebug v0.0.0-20170609050446-bfb01ae9c266 // indirect
-	gopkg.in/check.v1 v1.0.0-20201130134442-10cb98267c6c // indirect
 )
@@ -0,0 +1,312 @@
+package technical_debt
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+const (
+	GRAPH_DIRECT       = "direct"       // Every code file with its direct dependencies.
+	GRAPH_CONDENSATION = "condensation" // Every cyclical group with the direct dependencies between groups.
+)
+
+// Graph is a dependency graph of an analysis, ready to be written for other graph tools.
+type Graph struct {
+	Kind  string // Either direct or the condensation.
+	Nodes []GraphNode
+	Edges []GraphEdge
+}
+
+// GraphNode is a code file, or a cyclical group in the condensation.
+type GraphNode struct {
+	ID                string // The code file name, or the cyclic fingerprint of the group.
+	Label             string // The name without the prefix shared by every node.
+	Package           string // The package of the node, blank for a group spanning packages.
+	Partition         string
+	VisibilityFanIn   int
+	VisibilityFanOut  int
+	CyclicFingerprint string
+	GroupSize         int // The code files in the cyclical group.
+}
+
+// GraphEdge is a direct dependency between nodes.
+type GraphEdge struct {
+	From   string
+	To     string
+	Weight EdgeWeight // Summed over the code files of the groups in the condensation.
+}
+
+// CreateGraph gets either the direct dependency graph or the condensation of an analysis. The nodes are
+// in grid order and the edges are sorted.
+func CreateGraph(analysis Analysis, granularity, kind string) (graph Graph) {
+	graph.Kind = kind
+
+	// The partitions hold the code files in grid order.
+	for _, partition := range analysis.Partitions {
+		for _, group := range partition.Groups {
+			if kind == GRAPH_CONDENSATION {
+				graph.Nodes = append(graph.Nodes, GraphNode{
+					ID:                group.CyclicFingerprint,
+					Label:             graphLabel(analysis.Prefix, group.CyclicFingerprint),
+					Package:           groupPackage(group, granularity),
+					Partition:         partition.Name,
+					VisibilityFanIn:   group.VisibilityFanIn,
+					VisibilityFanOut:  group.VisibilityFanOut,
+					CyclicFingerprint: group.CyclicFingerprint,
+					GroupSize:         group.FileCount,
+				})
+				continue
+			}
+			for _, file := range group.Files {
+				codeFile := analysis.CodeFiles[file.Name]
+				graph.Nodes = append(graph.Nodes, GraphNode{
+					ID:                codeFile.Name,
+					Label:             graphLabel(analysis.Prefix, codeFile.Name),
+					Package:           nodePackage(codeFile.Name, granularity),
+					Partition:         partition.Name,
+					VisibilityFanIn:   codeFile.VisibilityFanIn,
+					VisibilityFanOut:  codeFile.VisibilityFanOut,
+					CyclicFingerprint: codeFile.CyclicFingerprint,
+					GroupSize:         group.FileCount,
+				})
+			}
+		}
+	}
+
+	// Dependencies inside a cyclical group vanish in the condensation, the rest are merged.
+	weights := map[Edge]EdgeWeight{}
+	for _, codeFile := range analysis.CodeFiles {
+		for dependsOnName := range codeFile.DependsOn {
+			edge := Edge{From: codeFile.Name, To: dependsOnName}
+			if kind == GRAPH_CONDENSATION {
+				edge = Edge{From: codeFile.CyclicFingerprint, To: analysis.CodeFiles[dependsOnName].CyclicFingerprint}
+				if edge.From == edge.To {
+					continue
+				}
+			}
+			weight := weights[edge]
+			weight.Symbols += codeFile.Weights[dependsOnName].Symbols
+			weight.References += codeFile.Weights[dependsOnName].References
+			weights[edge] = weight
+		}
+	}
+	for edge, weight := range weights {
+		graph.Edges = append(graph.Edges, GraphEdge{From: edge.From, To: edge.To, Weight: weight})
+	}
+	sort.Slice(graph.Edges, func(i, j int) bool {
+		if graph.Edges[i].From != graph.Edges[j].From {
+			return graph.Edges[i].From < graph.Edges[j].From
+		}
+		return graph.Edges[i].To < graph.Edges[j].To
+	})
+
+	return graph
+}
+
+// graphLabel is a node name without the prefix shared by every node.
+func graphLabel(prefix, name string) string {
+	if label := strings.TrimPrefix(name, prefix+"/"); label != "" {
+		return label
+	}
+	return name
+}
+
+// groupPackage is the package of every code file in a cyclical group, or blank if they are in different packages.
+func groupPackage(group CyclicalGroup, granularity string) (packageName string) {
+	for i, file := range group.Files {
+		filePackage := nodePackage(file.Name, granularity)
+		if i > 0 && filePackage != packageName {
+			return ""
+		}
+		packageName = filePackage
+	}
+	return packageName
+}
+
+// packageClusters gathers the nodes of a graph by their package, in the order packages are first seen.
+// The nodes with no single package come last, outside of any cluster.
+func (g Graph) packageClusters() (packages []string, clusters map[string][]GraphNode) {
+	clusters = map[string][]GraphNode{}
+	for _, node := range g.Nodes {
+		if _, ok := clusters[node.Package]; !ok && node.Package != "" {
+			packages = append(packages, node.Package)
+		}
+		clusters[node.Package] = append(clusters[node.Package], node)
+	}
+	return packages, clusters
+}
+
+// WriteDot writes a graph in the graphviz dot language, with the nodes of each package clustered together if wanted.
+func WriteDot(w io.Writer, graph Graph, clusterPackages bool) (err error) {
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %s {\n", dotQuote(graph.Kind))
+	b.WriteString("\tnode [shape=box];\n")
+
+	writeNode := func(indent string, node GraphNode) {
+		fmt.Fprintf(&b, "%s%s [label=%s, partition=%s, visibility_fan_in=%d, visibility_fan_out=%d, cyclic_group=%s, group_size=%d];\n",
+			indent, dotQuote(node.ID), dotQuote(node.Label), dotQuote(node.Partition),
+			node.VisibilityFanIn, node.VisibilityFanOut, dotQuote(node.CyclicFingerprint), node.GroupSize)
+	}
+	if clusterPackages {
+		packages, clusters := graph.packageClusters()
+		for i, packageName := range packages {
+			// Graphviz only draws subgraphs named with a cluster prefix as clusters.
+			fmt.Fprintf(&b, "\tsubgraph %s {\n", dotQuote(fmt.Sprintf("cluster_%d", i)))
+			fmt.Fprintf(&b, "\t\tlabel=%s;\n", dotQuote(packageName))
+			for _, node := range clusters[packageName] {
+				writeNode("\t\t", node)
+			}
+			b.WriteString("\t}\n")
+		}
+		for _, node := range clusters[""] {
+			writeNode("\t", node)
+		}
+	} else {
+		for _, node := range graph.Nodes {
+			writeNode("\t", node)
+		}
+	}
+
+	for _, edge := range graph.Edges {
+		fmt.Fprintf(&b, "\t%s -> %s [symbols=%d, references=%d];\n", dotQuote(edge.From), dotQuote(edge.To), edge.Weight.Symbols, edge.Weight.References)
+	}
+	b.WriteString("}\n")
+
+	if _, err = io.WriteString(w, b.String()); err != nil {
+		return Error(err)
+	}
+
+	return nil
+}
+
+// dotQuote makes a dot language string.
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+// graphmlDocument is a graphml file, http://graphml.graphdrawing.org/.
+type graphmlDocument struct {
+	XMLName xml.Name     `xml:"graphml"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	Keys    []graphmlKey `xml:"key"`
+	Graph   graphmlGraph `xml:"graph"`
+}
+
+// graphmlKey declares an attribute of nodes or edges.
+type graphmlKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+// graphmlGraph is a graph, or a graph nested in a node.
+type graphmlGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphmlNode `xml:"node"`
+	Edges       []graphmlEdge `xml:"edge"`
+}
+
+// graphmlNode is a node with its attributes.
+type graphmlNode struct {
+	ID    string        `xml:"id,attr"`
+	Data  []graphmlData `xml:"data"`
+	Graph *graphmlGraph `xml:"graph"` // The nodes of a package, when clustered.
+}
+
+// graphmlEdge is an edge with its attributes.
+type graphmlEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphmlData `xml:"data"`
+}
+
+// graphmlData is the value of an attribute.
+type graphmlData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// graphmlKeys declares the attributes of the nodes and edges.
+var graphmlKeys = []graphmlKey{
+	{ID: "label", For: "node", Name: "label", Type: "string"},
+	{ID: "package", For: "node", Name: "package", Type: "string"},
+	{ID: "partition", For: "node", Name: "partition", Type: "string"},
+	{ID: "visibility_fan_in", For: "node", Name: "visibility_fan_in", Type: "int"},
+	{ID: "visibility_fan_out", For: "node", Name: "visibility_fan_out", Type: "int"},
+	{ID: "cyclic_group", For: "node", Name: "cyclic_group", Type: "string"},
+	{ID: "group_size", For: "node", Name: "group_size", Type: "int"},
+	{ID: "symbols", For: "edge", Name: "symbols", Type: "int"},
+	{ID: "references", For: "edge", Name: "references", Type: "int"},
+}
+
+// WriteGraphML writes a graph as graphml, with the nodes of each package nested in a node for
+// the package if wanted.
+func WriteGraphML(w io.Writer, graph Graph, clusterPackages bool) (err error) {
+
+	graphNode := func(node GraphNode) graphmlNode {
+		return graphmlNode{ID: node.ID, Data: []graphmlData{
+			{Key: "label", Value: node.Label},
+			{Key: "package", Value: node.Package},
+			{Key: "partition", Value: node.Partition},
+			{Key: "visibility_fan_in", Value: fmt.Sprint(node.VisibilityFanIn)},
+			{Key: "visibility_fan_out", Value: fmt.Sprint(node.VisibilityFanOut)},
+			{Key: "cyclic_group", Value: node.CyclicFingerprint},
+			{Key: "group_size", Value: fmt.Sprint(node.GroupSize)},
+		}}
+	}
+
+	document := graphmlDocument{
+		Xmlns: "http://graphml.graphdrawing.org/xmlns",
+		Keys:  graphmlKeys,
+		Graph: graphmlGraph{ID: graph.Kind, EdgeDefault: "directed"},
+	}
+	if clusterPackages {
+		packages, clusters := graph.packageClusters()
+		for _, packageName := range packages {
+			// The nested graph has an id of its own, the package node id with a suffix.
+			clusterID := "package:" + packageName
+			cluster := &graphmlGraph{ID: clusterID + ":", EdgeDefault: "directed"}
+			for _, node := range clusters[packageName] {
+				cluster.Nodes = append(cluster.Nodes, graphNode(node))
+			}
+			document.Graph.Nodes = append(document.Graph.Nodes, graphmlNode{
+				ID:    clusterID,
+				Data:  []graphmlData{{Key: "label", Value: packageName}, {Key: "package", Value: packageName}},
+				Graph: cluster,
+			})
+		}
+		for _, node := range clusters[""] {
+			document.Graph.Nodes = append(document.Graph.Nodes, graphNode(node))
+		}
+	} else {
+		for _, node := range graph.Nodes {
+			document.Graph.Nodes = append(document.Graph.Nodes, graphNode(node))
+		}
+	}
+	for _, edge := range graph.Edges {
+		document.Graph.Edges = append(document.Graph.Edges, graphmlEdge{Source: edge.From, Target: edge.To, Data: []graphmlData{
+			{Key: "symbols", Value: fmt.Sprint(edge.Weight.Symbols)},
+			{Key: "references", Value: fmt.Sprint(edge.Weight.References)},
+		}})
+	}
+
+	if _, err = io.WriteString(w, xml.Header); err != nil {
+		return Error(err)
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "\t")
+	if err = encoder.Encode(document); err != nil {
+		return Error(err)
+	}
+	if _, err = io.WriteString(w, "\n"); err != nil {
+		return Error(err)
+	}
+
+	return nil
+}
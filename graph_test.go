@@ -0,0 +1,104 @@
+package technical_debt
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+
+	. "gopkg.in/check.v1" // https://labix.org/gocheck
+)
+
+// Create a suite.
+type GraphSuite struct{}
+
+var _ = Suite(&GraphSuite{})
+
+// Add the tests.
+
+// graphAnalysis is a small analysis with a cycle inside one package.
+func graphAnalysis(c *C) (analysis Analysis) {
+	codeFiles := testCodeFiles(map[string][]string{
+		"r/a/one.go":   {"r/a/two.go", "r/b/three.go"},
+		"r/a/two.go":   {"r/a/one.go", "r/b/three.go"},
+		"r/b/three.go": {},
+	})
+	codeFiles["r/a/one.go"].Weights["r/b/three.go"] = EdgeWeight{Symbols: 1, References: 2}
+	codeFiles["r/a/two.go"].Weights["r/b/three.go"] = EdgeWeight{Symbols: 2, References: 3}
+	analysis, err := Analyze(codeFiles, VIEW_CORE_PERIPHERY)
+	c.Assert(err, IsNil)
+	return analysis
+}
+
+func (s *GraphSuite) Test_CreateGraph(c *C) {
+	analysis := graphAnalysis(c)
+
+	direct := CreateGraph(analysis, GRANULARITY_FILE, GRAPH_DIRECT)
+	c.Assert(len(direct.Nodes), Equals, 3)
+	c.Check(direct.Nodes[0], Equals, GraphNode{ID: "r/b/three.go", Label: "b/three.go", Package: "r/b", Partition: PARTITION_SHARED, VisibilityFanIn: 3, VisibilityFanOut: 1, CyclicFingerprint: "r/b/three.go", GroupSize: 1})
+	c.Check(direct.Nodes[1].CyclicFingerprint, Equals, "r/a/one.go")
+	c.Check(direct.Nodes[1].Partition, Equals, PARTITION_CORE)
+	c.Check(direct.Edges, DeepEquals, []GraphEdge{
+		{From: "r/a/one.go", To: "r/a/two.go"},
+		{From: "r/a/one.go", To: "r/b/three.go", Weight: EdgeWeight{Symbols: 1, References: 2}},
+		{From: "r/a/two.go", To: "r/a/one.go"},
+		{From: "r/a/two.go", To: "r/b/three.go", Weight: EdgeWeight{Symbols: 2, References: 3}},
+	})
+
+	// The cycle becomes one node, with the edges out of it merged.
+	condensation := CreateGraph(analysis, GRANULARITY_FILE, GRAPH_CONDENSATION)
+	c.Assert(len(condensation.Nodes), Equals, 2)
+	c.Check(condensation.Nodes[1], Equals, GraphNode{ID: "r/a/one.go", Label: "a/one.go", Package: "r/a", Partition: PARTITION_CORE, VisibilityFanIn: 2, VisibilityFanOut: 3, CyclicFingerprint: "r/a/one.go", GroupSize: 2})
+	c.Check(condensation.Edges, DeepEquals, []GraphEdge{
+		{From: "r/a/one.go", To: "r/b/three.go", Weight: EdgeWeight{Symbols: 3, References: 5}},
+	})
+}
+
+func (s *GraphSuite) Test_WriteDot(c *C) {
+	graph := CreateGraph(graphAnalysis(c), GRANULARITY_FILE, GRAPH_CONDENSATION)
+
+	var buffer bytes.Buffer
+	c.Assert(WriteDot(&buffer, graph, false), IsNil)
+	c.Check(buffer.String(), Equals, ""+
+		"digraph \"condensation\" {\n"+
+		"\tnode [shape=box];\n"+
+		"\t\"r/b/three.go\" [label=\"b/three.go\", partition=\"shared\", visibility_fan_in=3, visibility_fan_out=1, cyclic_group=\"r/b/three.go\", group_size=1];\n"+
+		"\t\"r/a/one.go\" [label=\"a/one.go\", partition=\"core\", visibility_fan_in=2, visibility_fan_out=3, cyclic_group=\"r/a/one.go\", group_size=2];\n"+
+		"\t\"r/a/one.go\" -> \"r/b/three.go\" [symbols=3, references=5];\n"+
+		"}\n")
+
+	// Each package is a cluster.
+	buffer.Reset()
+	c.Assert(WriteDot(&buffer, graph, true), IsNil)
+	c.Check(strings.Contains(buffer.String(), "\tsubgraph \"cluster_0\" {\n\t\tlabel=\"r/b\";\n\t\t\"r/b/three.go\""), Equals, true)
+	c.Check(strings.Contains(buffer.String(), "\tsubgraph \"cluster_1\" {\n\t\tlabel=\"r/a\";\n\t\t\"r/a/one.go\""), Equals, true)
+
+	c.Check(dotQuote(`a"b\c`), Equals, `"a\"b\\c"`)
+}
+
+func (s *GraphSuite) Test_WriteGraphML(c *C) {
+	graph := CreateGraph(graphAnalysis(c), GRANULARITY_FILE, GRAPH_DIRECT)
+
+	var buffer bytes.Buffer
+	c.Assert(WriteGraphML(&buffer, graph, false), IsNil)
+	var document graphmlDocument
+	c.Assert(xml.Unmarshal(buffer.Bytes(), &document), IsNil)
+	c.Check(document.Xmlns, Equals, "http://graphml.graphdrawing.org/xmlns")
+	c.Check(len(document.Keys), Equals, len(graphmlKeys))
+	c.Check(document.Graph.EdgeDefault, Equals, "directed")
+	c.Assert(len(document.Graph.Nodes), Equals, 3)
+	c.Check(document.Graph.Nodes[0].ID, Equals, "r/b/three.go")
+	c.Check(document.Graph.Nodes[0].Data[2], Equals, graphmlData{Key: "partition", Value: PARTITION_SHARED})
+	c.Check(len(document.Graph.Edges), Equals, 4)
+	c.Check(document.Graph.Edges[1].Data[1], Equals, graphmlData{Key: "references", Value: "2"})
+
+	// Each package is a node with a graph inside it.
+	buffer.Reset()
+	c.Assert(WriteGraphML(&buffer, graph, true), IsNil)
+	document = graphmlDocument{}
+	c.Assert(xml.Unmarshal(buffer.Bytes(), &document), IsNil)
+	c.Assert(len(document.Graph.Nodes), Equals, 2)
+	c.Check(document.Graph.Nodes[0].ID, Equals, "package:r/b")
+	c.Assert(document.Graph.Nodes[1].Graph, NotNil)
+	c.Check(len(document.Graph.Nodes[1].Graph.Nodes), Equals, 2)
+	c.Check(len(document.Graph.Edges), Equals, 4)
+}
@@ -0,0 +1,48 @@
+package technical_debt
+
+import (
+	"go/scanner"
+	"go/token"
+	"sort"
+)
+
+// codeLines finds the lines of a file with code on them, in order. Blank lines and lines with only
+// comments are left out.
+func codeLines(data []byte) (lines []int) {
+
+	// The scanner needs a file of its own to number the lines.
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(data))
+
+	var s scanner.Scanner
+	s.Init(file, data, nil, 0) // Errors are left to the parser.
+	seen := map[int]bool{}
+	for {
+		pos, tok, literal := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		// The newlines and end of file that end statements are not code.
+		if tok == token.SEMICOLON && literal != ";" {
+			continue
+		}
+		if line := file.Line(pos); !seen[line] {
+			seen[line] = true
+			lines = append(lines, line)
+		}
+	}
+	sort.Ints(lines)
+
+	return lines
+}
+
+// countLines sets the lines of code of a parsed file and each of its declarations.
+func countLines(fset *token.FileSet, file *packageFile, data []byte) {
+	lines := codeLines(data)
+	file.lines = len(lines)
+	for i, declarationRange := range file.ranges {
+		first := fset.Position(declarationRange.pos).Line
+		last := fset.Position(declarationRange.end - 1).Line
+		file.ranges[i].lines = sort.SearchInts(lines, last+1) - sort.SearchInts(lines, first)
+	}
+}
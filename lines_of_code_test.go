@@ -0,0 +1,39 @@
+package technical_debt
+
+import (
+	. "gopkg.in/check.v1" // https://labix.org/gocheck
+)
+
+// Create a suite.
+type LinesOfCodeSuite struct{}
+
+var _ = Suite(&LinesOfCodeSuite{})
+
+// Add the tests.
+
+func (s *LinesOfCodeSuite) Test_CodeLines(c *C) {
+	source := "// Package doc.\npackage a\n\n/* A\nblock. */\nvar x = `raw\nstring` // Trailing.\n\nfunc f() { return }\n"
+	c.Check(codeLines([]byte(source)), DeepEquals, []int{2, 6, 9})
+	c.Check(codeLines([]byte("")), IsNil)
+}
+
+func (s *LinesOfCodeSuite) Test_Lines(c *C) {
+	// Blank lines and comments are left out.
+	codeFiles := fixtureCodeFiles(c, "testdata/generics", Config{})
+	c.Check(codeFiles["example.com/generics/collections/sum.go"].Lines, Equals, 10)
+
+	codeFiles = fixtureCodeFiles(c, "testdata/generics", Config{Granularity: GRANULARITY_DECLARATION})
+	c.Check(codeFiles["example.com/generics/collections/sum.go:Sum"].Lines, Equals, 6)
+
+	// Packages have the lines of all their files.
+	var total int
+	codeFiles = fixtureCodeFiles(c, "testdata/generics", Config{})
+	for name, codeFile := range codeFiles {
+		if packageNodeName(name) == "example.com/generics/collections" {
+			total += codeFile.Lines
+		}
+	}
+	codeFiles = fixtureCodeFiles(c, "testdata/generics", Config{Granularity: GRANULARITY_PACKAGE})
+	c.Check(codeFiles["example.com/generics/collections"].Lines, Equals, total)
+	c.Check(CollapseToPackages(fixtureCodeFiles(c, "testdata/generics", Config{}))["example.com/generics/collections"].Lines, Equals, total)
+}
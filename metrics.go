@@ -1,12 +1,16 @@
 package technical_debt
 
-// CalculateMetrics calcualtes important nubmer for the algorithm.
-func CalculateMetrics(codeFiles map[string]CodeFile) (propogationCost float64) {
+import (
+	"fmt"
+)
+
+// CalculateMetrics calcualtes important nubmer for the algorithm, counting the deep dependencies in the closure.
+func CalculateMetrics(codeFiles map[string]CodeFile, closure Closure) (propogationCost float64, err error) {
 
 	for filename := range codeFiles {
 		codeFile := codeFiles[filename]
-		codeFile.VisibilityFanIn = len(codeFile.DependedOnBy)
-		codeFile.VisibilityFanOut = len(codeFile.DependsOn)
+		codeFile.VisibilityFanIn = closure.VisibilityFanIn(filename)
+		codeFile.VisibilityFanOut = closure.VisibilityFanOut(filename)
 		codeFiles[filename] = codeFile
 	}
 
@@ -19,9 +23,31 @@ func CalculateMetrics(codeFiles map[string]CodeFile) (propogationCost float64) {
 
 	// Sanity check.
 	if totalFanIn != totalFanOut {
-		panic(`total fan in and fan out should be identical`)
+		return 0, Error(&GraphError{Message: fmt.Sprintf(`total fan in %d and fan out %d should be identical`, totalFanIn, totalFanOut)})
+	}
+
+	// No files have no cost.
+	if len(codeFiles) == 0 {
+		return 0, nil
 	}
 
 	propogationCost = float64(totalFanIn) / float64(len(codeFiles)*len(codeFiles))
-	return propogationCost
+	return propogationCost, nil
+}
+
+// CalculateDirectDensity calculates the fraction of the grid that is direct dependencies, the density
+// of the dependency graph before it is driven deep.
+func CalculateDirectDensity(codeFiles map[string]CodeFile) (directDensity float64) {
+
+	// No files have no density.
+	if len(codeFiles) == 0 {
+		return 0
+	}
+
+	var directCount int
+	for _, codeFile := range codeFiles {
+		directCount += len(codeFile.DependsOn)
+	}
+
+	return float64(directCount) / float64(len(codeFiles)*len(codeFiles))
 }
@@ -0,0 +1,149 @@
+package technical_debt
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Module is a body of code whose packages are all part of the project.
+type Module struct {
+	Path string // The module path from go.mod, blank for a GOPATH source root.
+	Dir  string // The directory the import paths are relative to.
+}
+
+// LoadModules finds the modules of a project from a go.work or go.mod in the root directory.
+func LoadModules(root string) (modules []Module, err error) {
+
+	// A workspace takes precedence over a single module, just as with the go tool.
+	workFilename := filepath.Join(root, "go.work")
+	if _, err = os.Stat(workFilename); err == nil {
+		var data []byte
+		if data, err = ioutil.ReadFile(workFilename); err != nil {
+			return nil, Error(err)
+		}
+		var useDirs []string
+		if useDirs, err = parseWorkUses(workFilename, data); err != nil {
+			return nil, Error(err)
+		}
+		for _, useDir := range useDirs {
+			if !filepath.IsAbs(useDir) {
+				useDir = filepath.Join(root, useDir)
+			}
+			var module Module
+			if module, err = loadModule(useDir); err != nil {
+				return nil, Error(err)
+			}
+			modules = append(modules, module)
+		}
+		if len(modules) == 0 {
+			return nil, Errorf(`%s uses no modules`, workFilename)
+		}
+		return modules, nil
+	}
+
+	// No workspace, the root must be a single module.
+	module, err := loadModule(root)
+	if err != nil {
+		return nil, Error(err)
+	}
+
+	return []Module{module}, nil
+}
+
+// loadModule reads the go.mod in a directory.
+func loadModule(dir string) (module Module, err error) {
+
+	modFilename := filepath.Join(dir, "go.mod")
+	data, err := ioutil.ReadFile(modFilename)
+	if err != nil {
+		return Module{}, Error(err)
+	}
+
+	modulePath, err := parseModulePath(modFilename, data)
+	if err != nil {
+		return Module{}, Error(err)
+	}
+
+	// Keep directories absolute so import paths can be derived from any working directory.
+	if dir, err = filepath.Abs(dir); err != nil {
+		return Module{}, Error(err)
+	}
+
+	return Module{Path: modulePath, Dir: dir}, nil
+}
+
+// parseModulePath finds the module directive in the contents of a go.mod file.
+func parseModulePath(filename string, data []byte) (modulePath string, err error) {
+
+	for _, line := range modFileLines(data) {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "module" {
+			return unquoteModFileValue(filename, fields[1])
+		}
+	}
+
+	return "", Errorf(`%s has no module directive`, filename)
+}
+
+// parseWorkUses finds the directories of the use directives in the contents of a go.work file.
+func parseWorkUses(filename string, data []byte) (useDirs []string, err error) {
+
+	var inUseBlock bool
+	for _, line := range modFileLines(data) {
+		fields := strings.Fields(line)
+		switch {
+		case inUseBlock && len(fields) == 1 && fields[0] == ")":
+			inUseBlock = false
+		case inUseBlock && len(fields) == 1:
+			var useDir string
+			if useDir, err = unquoteModFileValue(filename, fields[0]); err != nil {
+				return nil, Error(err)
+			}
+			useDirs = append(useDirs, useDir)
+		case len(fields) == 2 && fields[0] == "use" && fields[1] == "(":
+			inUseBlock = true
+		case len(fields) == 2 && fields[0] == "use":
+			var useDir string
+			if useDir, err = unquoteModFileValue(filename, fields[1]); err != nil {
+				return nil, Error(err)
+			}
+			useDirs = append(useDirs, useDir)
+		}
+	}
+
+	return useDirs, nil
+}
+
+// modFileLines splits a go.mod or go.work file into lines without comments.
+func modFileLines(data []byte) (lines []string) {
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
+
+// unquoteModFileValue removes the optional quoting around a path in a go.mod or go.work file.
+func unquoteModFileValue(filename, value string) (unquoted string, err error) {
+	if !strings.HasPrefix(value, `"`) && !strings.HasPrefix(value, "`") {
+		return value, nil
+	}
+	if unquoted, err = strconv.Unquote(value); err != nil {
+		return "", Errorf(`%s has malformed path %s: %s`, filename, value, err.Error())
+	}
+	return unquoted, nil
+}
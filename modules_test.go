@@ -0,0 +1,102 @@
+package technical_debt
+
+import (
+	. "gopkg.in/check.v1" // https://labix.org/gocheck
+
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Create a suite.
+type ModulesSuite struct{}
+
+var _ = Suite(&ModulesSuite{})
+
+// Add the tests.
+
+func (s *ModulesSuite) Test_ParseModulePath(c *C) {
+	tests := []struct {
+		data       string
+		modulePath string
+		errorMsg   string
+	}{
+		{
+			data:       "module github.com/a/b\n\ngo 1.19\n",
+			modulePath: "github.com/a/b",
+		},
+		{
+			data:       "// A comment.\nmodule \"github.com/a/b\" // Quoted.\n",
+			modulePath: "github.com/a/b",
+		},
+		{
+			data:     "go 1.19\n",
+			errorMsg: "go.mod has no module directive(?s).*",
+		},
+	}
+	for i, test := range tests {
+		comment := Commentf("Case %v: %v", i, test)
+		modulePath, err := parseModulePath("go.mod", []byte(test.data))
+		if test.errorMsg != "" {
+			c.Check(err, ErrorMatches, test.errorMsg, comment)
+		} else {
+			c.Check(err, IsNil, comment)
+		}
+		c.Check(modulePath, Equals, test.modulePath, comment)
+	}
+}
+
+func (s *ModulesSuite) Test_ParseWorkUses(c *C) {
+	data := "go 1.19\n\nuse ./single\n\nuse (\n\t./a // First.\n\t\"./b\"\n)\n"
+	useDirs, err := parseWorkUses("go.work", []byte(data))
+	c.Assert(err, IsNil)
+	c.Assert(useDirs, DeepEquals, []string{"./single", "./a", "./b"})
+}
+
+func (s *ModulesSuite) Test_LoadModules(c *C) {
+	root := c.MkDir()
+	writeTestFile(c, filepath.Join(root, "go.work"), "go 1.19\n\nuse (\n\t./a\n\t./b\n)\n")
+	writeTestFile(c, filepath.Join(root, "a", "go.mod"), "module example.com/a\n")
+	writeTestFile(c, filepath.Join(root, "a", "a.go"), "package a\n")
+	writeTestFile(c, filepath.Join(root, "a", "inner", "inner.go"), "package inner\n")
+	writeTestFile(c, filepath.Join(root, "a", "nested", "go.mod"), "module example.com/nested\n")
+	writeTestFile(c, filepath.Join(root, "a", "nested", "nested.go"), "package nested\n")
+	writeTestFile(c, filepath.Join(root, "b", "go.mod"), "module example.com/b\n")
+	writeTestFile(c, filepath.Join(root, "b", "b.go"), "package b\n")
+
+	modules, err := LoadModules(root)
+	c.Assert(err, IsNil)
+	c.Assert(modules, DeepEquals, []Module{
+		{Path: "example.com/a", Dir: filepath.Join(root, "a")},
+		{Path: "example.com/b", Dir: filepath.Join(root, "b")},
+	})
+
+	// The nested module is not part of the workspace.
+	packages, err := PackagePaths(modules, Config{})
+	c.Assert(err, IsNil)
+	c.Assert(packages, DeepEquals, []packageLocation{
+		{importPath: "example.com/a", dir: filepath.Join(root, "a"), relativeDir: "."},
+		{importPath: "example.com/a/inner", dir: filepath.Join(root, "a", "inner"), relativeDir: "inner"},
+		{importPath: "example.com/b", dir: filepath.Join(root, "b"), relativeDir: "."},
+	})
+
+	// Paths narrow down the packages.
+	packages, err = PackagePaths(modules, Config{Paths: []string{"example.com/a/inner"}})
+	c.Assert(err, IsNil)
+	c.Assert(packages, DeepEquals, []packageLocation{
+		{importPath: "example.com/a/inner", dir: filepath.Join(root, "a", "inner"), relativeDir: "inner"},
+	})
+}
+
+func (s *ModulesSuite) Test_InProject(c *C) {
+	c.Check(inProject("example.com/a", []string{"example.com/a"}), Equals, true)
+	c.Check(inProject("example.com/a/b", []string{"example.com/a"}), Equals, true)
+	c.Check(inProject("example.com/ab", []string{"example.com/a"}), Equals, false)
+	c.Check(inProject("fmt", []string{"example.com/a"}), Equals, false)
+}
+
+// writeTestFile creates a file, and any missing directories, for a test.
+func writeTestFile(c *C, filename, contents string) {
+	c.Assert(os.MkdirAll(filepath.Dir(filename), 0755), IsNil)
+	c.Assert(ioutil.WriteFile(filename, []byte(contents), 0644), IsNil)
+}
@@ -2,45 +2,107 @@ package technical_debt
 
 import (
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
+	"strings"
 )
 
-// PackagePaths gets all the paths that belong to this go path.
-func PackagePaths(gopath string, paths []string) (packagePaths []string, err error) {
+// packageLocation is where a package is found on disk.
+type packageLocation struct {
+	importPath  string // The full import path of the package.
+	dir         string // The directory with the package source.
+	relativeDir string // The directory relative to the module, slash separated, for matching globs.
+}
 
-	// We're building a list of package paths.
-	var packagePathSet map[string]bool = map[string]bool{} // A map as a set.
+// PackagePaths gets all the packages in the modules that are under the config paths and not excluded.
+// With no paths, every package of the modules is included.
+func PackagePaths(modules []Module, config Config) (packages []packageLocation, err error) {
+	var paths []string = config.Paths
 
-	// The tree walk function.
-	var walkFunc filepath.WalkFunc = func(path string, info os.FileInfo, err2 error) (err3 error) {
-		if err2 != nil {
-			return err2
-		}
-		// Is this a go file?
-		if filepath.Ext(path) == ".go" {
-			// Add this package path.
-			var fullPackagePath string = filepath.Dir(path)
-			// This is a full path. Chop off the source root path.
-			var packagePath string
-			if packagePath, err3 = filepath.Rel(gopath, fullPackagePath); err3 != nil {
-				return err3
+	// We're building a set of package locations.
+	var packageSet map[string]packageLocation = map[string]packageLocation{} // A map as a set.
+
+	for _, module := range modules {
+
+		// The tree walk function.
+		var walkFunc filepath.WalkFunc = func(filePath string, info os.FileInfo, err2 error) (err3 error) {
+			if err2 != nil {
+				return err2
 			}
-			packagePathSet[packagePath] = true
+			if info.IsDir() {
+				if filePath == module.Dir {
+					return nil
+				}
+				// The go tool ignores these directories.
+				if ignoredDir(info.Name()) {
+					return filepath.SkipDir
+				}
+				var relativeDir string
+				if relativeDir, err3 = filepath.Rel(module.Dir, filePath); err3 != nil {
+					return err3
+				}
+				if config.excludedPath(filepath.ToSlash(relativeDir)) {
+					return filepath.SkipDir
+				}
+				// A nested module is not part of this module.
+				if module.Path != "" {
+					if _, err3 = os.Stat(filepath.Join(filePath, "go.mod")); err3 == nil {
+						return filepath.SkipDir
+					}
+				}
+				return nil
+			}
+			// Is this a go file?
+			if filepath.Ext(filePath) == ".go" {
+				// Add this package.
+				var dir string = filepath.Dir(filePath)
+				// This is a full path. Chop off the module root path.
+				var relativeDir string
+				if relativeDir, err3 = filepath.Rel(module.Dir, dir); err3 != nil {
+					return err3
+				}
+				var importPath string = path.Join(module.Path, filepath.ToSlash(relativeDir))
+				if len(paths) == 0 || inProject(importPath, paths) {
+					packageSet[importPath] = packageLocation{importPath: importPath, dir: dir, relativeDir: filepath.ToSlash(relativeDir)}
+				}
+			}
+			return nil
 		}
-		return nil
-	}
 
-	// Gather every folder that has at least a single .go file.
-	for _, path := range paths {
-		if err = filepath.Walk(gopath+"/"+path, walkFunc); err != nil {
-			return nil, Error(err)
+		// Gather every folder that has at least a single .go file.
+		var roots []string
+		if module.Path == "" {
+			// Without a module path the paths are directories under the source root.
+			for _, relativePath := range paths {
+				roots = append(roots, filepath.Join(module.Dir, filepath.FromSlash(relativePath)))
+			}
+		} else {
+			roots = []string{module.Dir}
+		}
+		for _, root := range roots {
+			if err = filepath.Walk(root, walkFunc); err != nil {
+				return nil, Error(err)
+			}
 		}
 	}
 
-	// Convert the set ot a list.
-	for path := range packagePathSet {
-		packagePaths = append(packagePaths, path)
+	// Convert the set to a sorted list.
+	for _, location := range packageSet {
+		packages = append(packages, location)
 	}
+	sort.Slice(packages, func(i, j int) bool { return packages[i].importPath < packages[j].importPath })
 
-	return packagePaths, nil
+	return packages, nil
+}
+
+// inProject reports whether an import path is one of the project paths or beneath them.
+func inProject(importPath string, projectPaths []string) bool {
+	for _, projectPath := range projectPaths {
+		projectPath = strings.TrimSuffix(projectPath, "/")
+		if importPath == projectPath || strings.HasPrefix(importPath, projectPath+"/") {
+			return true
+		}
+	}
+	return false
 }
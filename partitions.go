@@ -9,7 +9,15 @@ const (
 	VIEW_MEDIAN         = "median"
 )
 
+const (
+	PARTITION_SHARED    = "shared"
+	PARTITION_CORE      = "core"
+	PARTITION_PERIPHERY = "periphery"
+	PARTITION_CONTROL   = "control"
+)
+
 type Partition struct {
+	Name         string // Which of shared, core, periphery or control the partition is.
 	LowestIndex  int
 	HighestIndex int
 	FileCount    int
@@ -42,6 +50,11 @@ func FindMedianVisibilityFanInOut(fileLookup map[string]CodeFile) (visibilityFan
 	}
 	sort.Sort(byCyclicalPoperties(codeFiles))
 
+	// Without files there is no median.
+	if fileCount == 0 {
+		return 0, 0
+	}
+
 	// Is there an odd or even numbered file count?
 	if fileCount%2 == 0 {
 		// An even number, we need to find the middle two files. Examples:
@@ -80,7 +93,7 @@ func CreateViewPartions(groups []CyclicalGroup, visibilityFanIn, visibilityFanOu
 	// a) "Shared" elements have VFI ≥ VFIC and VFO < VFOC.
 	// b) "Peripheral" elements have VFI < VFIC and VFO < VFOC.
 	// c) "Control" elements have VFI < VFIC and VFO ≥ VFOC.
-	var core, shared, periphery, control Partition
+	core, shared, periphery, control := Partition{Name: PARTITION_CORE}, Partition{Name: PARTITION_SHARED}, Partition{Name: PARTITION_PERIPHERY}, Partition{Name: PARTITION_CONTROL}
 	for _, group := range groups {
 		if group.VisibilityFanIn >= visibilityFanIn && group.VisibilityFanOut < visibilityFanOut {
 			// This is s shared group.
@@ -1,13 +1,27 @@
 package technical_debt
 
 import (
+	"errors"
 	"fmt"
+	"go/ast"
 	"go/parser"
 	"go/token"
+	"go/types"
 	"io/ioutil"
+	"os"
+	"path"
 	"path/filepath"
+	"runtime"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+)
+
+const (
+	PARSE_ERRORS_ABORT   = "abort"   // Stop at the first error.
+	PARSE_ERRORS_EXCLUDE = "exclude" // Leave out files with errors.
+	PARSE_ERRORS_PARTIAL = "partial" // Keep whatever could be parsed from files with errors.
 )
 
 type packageFolder struct {
@@ -27,10 +41,17 @@ func (f packageFolder) String() (output string) {
 }
 
 type packageFile struct {
-	name         string // Name of this file.
+	name         string    // Name of this file.
+	path         string    // The path of this file on disk.
+	syntax       *ast.File // The parsed source of this file.
+	ranges       []declarationRange
 	imports      map[string]fileImport
+	dotImports   []string // The in-project packages whose names are used unqualified.
 	declarations []fileDeclaration
+	types        []fileType // The structs and interfaces declared, for what is selected from them.
 	unresolved   []fileUnresolved
+	generated    bool // The file is generated and marked to be shown apart.
+	lines        int  // The lines of code, without blank lines and comments.
 }
 
 func (f packageFile) String() (output string) {
@@ -41,6 +62,7 @@ func (f packageFile) String() (output string) {
 		for _, imp := range f.imports {
 			importStrings = append(importStrings, imp.String())
 		}
+		sort.Strings(importStrings)
 		importString = fmt.Sprintf("IMPORTS:\n%s\n", strings.Join(importStrings, "\n"))
 	}
 
@@ -78,187 +100,622 @@ func (i fileImport) String() (output string) {
 type fileDeclaration struct {
 	kind string
 	name string
+	node string // The top level declaration this name is part of.
 }
 
 func (d fileDeclaration) String() (output string) {
 	return fmt.Sprintf("\t%s (%s)\n", d.name, d.kind)
 }
 
+// fileType is a struct or interface declared at the top level of a file.
+type fileType struct {
+	name    string
+	members map[string]bool // The fields of a struct or the methods of an interface, declared in it.
+	embeds  []typeReference // The project types embedded in it, whose fields and methods are promoted.
+}
+
+// typeReference names a type declared at the top level of a package.
+type typeReference struct {
+	importPath string
+	name       string
+}
+
 type fileUnresolved struct {
-	packageName string // Relevant package.
-	name        string // The active item if in this package or a project package. "*" if for an out-of-project package.
+	packageName string              // Relevant package.
+	name        string              // The active item if in this package or a project package. "*" if for an out-of-project package.
+	receiver    string              // The type the name is selected from, for the methods of values.
+	from        string              // The top level declaration in this file making the reference.
+	target      declarationLocation // The declaration, if already known from type checking.
+	packageInit bool                // The whole package is depended on to be initialized.
+	count       int                 // How many times the reference is made.
 }
 
 func (u fileUnresolved) String() (output string) {
-	return fmt.Sprintf("\t%s.%s\n", u.packageName, u.name)
+	if u.packageInit {
+		return fmt.Sprintf("\t%s -> %s (init)\n", u.from, u.packageName)
+	}
+	if u.target.file != "" {
+		return fmt.Sprintf("\t%s -> %s\n", u.from, u.target.nodeName(GRANULARITY_DECLARATION))
+	}
+	return fmt.Sprintf("\t%s -> %s.%s\n", u.from, u.packageName, u.name)
 }
 
 type unresolvedName struct {
-	offset int    // The byte offset into the source file with the reference.
-	name   string // The text string of the unresolved refernce. May only be the package name of a longer identifier.
+	pos  token.Pos // The position of the reference.
+	name string    // The text string of the unresolved refernce. May only be the package name of a longer identifier.
 }
 
 // ProcessPackage processes all the tokens of a single package.
-func ProcessPackage(gopath string, packagePaths, projectPaths []string, includeTests bool) (folders []packageFolder, err error) {
+// Depending on the config, source errors are diagnostics that leave out or partially include the file.
+func ProcessPackage(config Config, packages []packageLocation, projectPaths []string) (folders []packageFolder, diagnostics []SourceError, err error) {
+
+	// All packages share positions so type checking can follow references between them.
+	fset := token.NewFileSet() // positions are relative to fset
+
+	// Find every file to parse, in a fixed order so the results never depend on scheduling.
+	var jobs []fileJob
+	for _, location := range packages {
+		var infos []os.FileInfo
+		if infos, err = ioutil.ReadDir(location.dir); err != nil {
+			return nil, nil, Error(err)
+		}
+		for _, info := range infos {
+			// Continue with this file if we are including both tests and code files, or this is not a test.
+			var name string = info.Name()
+			if info.IsDir() || filepath.Ext(name) != ".go" || (!config.analyzeTests() && isTestFile(name)) {
+				continue
+			}
+			if !config.includedFile(path.Join(location.relativeDir, name)) {
+				continue
+			}
+			jobs = append(jobs, fileJob{location: location, filename: filepath.Join(location.dir, name)})
+		}
+	}
+
+	// Parse the files with a pool of workers, each result in the slot of its job.
+	var concurrency int = config.Concurrency
+	if concurrency <= 0 {
+		concurrency = runtime.NumCPU()
+	}
+	results := make([]fileResult, len(jobs))
+	jobIndexes := make(chan int)
+	var waitGroup sync.WaitGroup
+	for worker := 0; worker < concurrency; worker++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			for i := range jobIndexes {
+				results[i] = processFile(fset, jobs[i], projectPaths, config)
+			}
+		}()
+	}
+	for i := range jobs {
+		jobIndexes <- i
+	}
+	close(jobIndexes)
+	waitGroup.Wait()
+
+	// Gather the files into their folders. A directory may hold more than one package.
+	var folderIndexes map[string]int = map[string]int{}
+	for i, result := range results {
+		diagnostics = append(diagnostics, result.diagnostics...)
+		if result.err != nil {
+			// When tolerating errors, a problem in the source only means the file is left out.
+			var sourceErr *SourceError
+			if config.ParseErrors != PARSE_ERRORS_ABORT && config.ParseErrors != "" && errors.As(result.err, &sourceErr) {
+				diagnostics = append(diagnostics, *sourceErr)
+				continue
+			}
+			return nil, nil, Error(result.err)
+		}
+		if result.excluded {
+			continue
+		}
+		var importPath string = folderImportPath(jobs[i].location.importPath, result.packageName, jobs[i].filename)
+		var key string = importPath + " " + result.packageName
+		index, ok := folderIndexes[key]
+		if !ok {
+			index = len(folders)
+			folderIndexes[key] = index
+			folders = append(folders, packageFolder{name: result.packageName, importPath: importPath})
+		}
+		folders[index].files = append(folders[index].files, result.file)
+	}
+	sort.SliceStable(folders, func(i, j int) bool {
+		if folders[i].importPath == folders[j].importPath {
+			return folders[i].name < folders[j].name
+		}
+		return folders[i].importPath < folders[j].importPath
+	})
+
+	// Predeclared names are only references when the package declares the name itself.
+	dropUniverseNames(folders)
+
+	// Type checking replaces the references found from the syntax alone.
+	if config.Resolver == RESOLVER_TYPES {
+		if err = resolveTypes(fset, folders, projectPaths); err != nil {
+			return nil, nil, Error(err)
+		}
+	}
+
+	return folders, diagnostics, nil
+}
+
+// fileJob is a single file to parse.
+type fileJob struct {
+	location packageLocation // The package the file is in.
+	filename string          // The path of the file on disk.
+}
+
+// fileResult is a single parsed file.
+type fileResult struct {
+	file        packageFile
+	packageName string        // The package clause of the file.
+	diagnostics []SourceError // The errors tolerated in the file.
+	excluded    bool          // The file was left out, for its errors, build constraints or being generated.
+	err         error
+}
+
+// processFile parses a single file and gathers its imports, declarations and references.
+func processFile(fset *token.FileSet, job fileJob, projectPaths []string, config Config) (result fileResult) {
+	var err error
+	var filename string = job.filename
+	var parseErrors string = config.ParseErrors
+
+	// Read the file once, the text is needed for both build constraints and parsing.
+	var data []byte
+	if data, err = ioutil.ReadFile(filename); err != nil {
+		return fileResult{err: Error(err)}
+	}
+
+	// Only files in the build are analyzed, otherwise declarations for different platforms collide.
+	var matches bool
+	if matches, err = matchesBuild(config, filename, data); err != nil {
+		return fileResult{err: Error(err)}
+	}
+	if !matches {
+		result.excluded = true
+		return result
+	}
+
+	// Generated files are left out or marked when asked for.
+	var generated bool = (config.Generated == GENERATED_EXCLUDE || config.Generated == GENERATED_MARK) && generatedFile(data)
+	if generated && config.Generated == GENERATED_EXCLUDE {
+		result.excluded = true
+		return result
+	}
+
+	// Only stop at the first error if that is all that will be reported.
+	var mode parser.Mode
+	if parseErrors == PARSE_ERRORS_ABORT || parseErrors == "" {
+		parsedFile, err := parser.ParseFile(fset, filename, data, mode)
+		if err != nil {
+			return fileResult{err: Error(parseSourceError(err))}
+		}
+		result.file, result.packageName, result.err = processParsedFile(fset, job, parsedFile, projectPaths)
+		result.file.generated = generated
+		countLines(fset, &result.file, data)
+		return result
+	}
+
+	// Tolerate the errors, keeping what the parser could make sense of if wanted.
+	parsedFile, err := parser.ParseFile(fset, filename, data, mode|parser.AllErrors)
+	if err != nil {
+		result.diagnostics = parseDiagnostics(err)
+		if parseErrors == PARSE_ERRORS_EXCLUDE || parsedFile == nil || parsedFile.Name == nil || parsedFile.Name.Name == "" {
+			result.excluded = true
+			return result
+		}
+	}
+	result.file, result.packageName, result.err = processParsedFile(fset, job, parsedFile, projectPaths)
+	result.file.generated = generated
+	countLines(fset, &result.file, data)
+	return result
+}
+
+// processParsedFile gathers the imports, declarations and references of a parsed file.
+func processParsedFile(fset *token.FileSet, job fileJob, parsedFile *ast.File, projectPaths []string) (file packageFile, packageName string, err error) {
 	var ok bool
+	var filename string = job.filename
+
+	packageName = parsedFile.Name.Name
+
+	// The folder the file will be gathered into.
+	var folder packageFolder
+	folder.name = packageName
+	folder.importPath = folderImportPath(job.location.importPath, packageName, filename)
 
-	// Go through every package.
-	for _, packagePath := range packagePaths {
-		fset := token.NewFileSet() // positions are relative to fset
+	file.name = filepath.Base(filename)
+	file.path = filename
+	file.syntax = parsedFile
+	file.ranges = declarationRanges(parsedFile)
 
-		// Parse the package.
-		p, err := parser.ParseDir(fset, gopath+"/"+packagePath, nil, 0)
+	// Get all the imports.
+	file.imports = map[string]fileImport{}
+	var blankImports []string
+	for _, s := range parsedFile.Imports {
+
+		// Get the import path in a usuable format.
+		importPath, err := strconv.Unquote(s.Path.Value)
 		if err != nil {
-			return nil, Error(err)
-		}
-
-		for _, parsedPackage := range p {
-			var folder packageFolder
-			folder.name = parsedPackage.Name
-			folder.importPath = packagePath
-
-			for filename, parsedFile := range parsedPackage.Files {
-				var file packageFile
-
-				// Continue with this file if we are including both tests and code files, or this is not a test.
-				if includeTests || !strings.HasSuffix(filename, "_test.go") {
-					file.name = filepath.Base(filename)
-
-					// We'll need the text of the file later.
-					var data []byte
-					if data, err = ioutil.ReadFile(filename); err != nil {
-						return nil, Error(err)
-					}
-					var fileText string = string(data)
-
-					// Get all the imports.
-					file.imports = map[string]fileImport{}
-					for _, s := range parsedFile.Imports {
-
-						// Get the import path in a usuable format.
-						importPath, err := strconv.Unquote(s.Path.Value)
-						if err != nil {
-							return nil, Error(err)
-						}
-
-						// Only continue with import paths that are in our projects.
-						inProject := false
-						for _, projectPath := range projectPaths {
-							if strings.HasPrefix(importPath, projectPath) {
-								inProject = true
-								break
-							}
-						}
-
-						// If this is in our projects we want to look for dependencies.
-						if inProject {
-							var importName string = filepath.Base(importPath)
-							if s.Name != nil && s.Name.Name != "." {
-								importName = s.Name.Name
-							}
-							file.imports[importName] = fileImport{name: importName, path: importPath, inProject: true}
-						}
-					}
-
-					for _, object := range parsedFile.Scope.Objects {
-						file.declarations = append(file.declarations, fileDeclaration{kind: object.Kind.String(), name: object.Name})
-					}
-
-					// All unresolved.
-					var rawUnresolvedNames []unresolvedName
-					for _, unresolved := range parsedFile.Unresolved {
-						rawUnresolvedNames = append(rawUnresolvedNames, unresolvedName{
-							offset: fset.Position(unresolved.NamePos).Offset,
-							name:   unresolved.Name,
-						})
-					}
-
-					// Drop anything that is definitely not a connection to another code file.
-					// Imports to non-project packages can disovered just with the import data structure.
-					var validUnresolvedNames []unresolvedName
-					for _, unresolved := range rawUnresolvedNames {
-						switch unresolved.name {
-						case "error", "nil", "bool", "string", "true", "false", "uint", "int", "uint64", "int64", "float64":
-							// Not a name to keep, not a link to another file.
-						default:
-							validUnresolvedNames = append(validUnresolvedNames, unresolved)
-						}
-					}
-
-					// Collapse to an actually distinct set of accurate references.
-					var unresolvedSet map[string]fileUnresolved = map[string]fileUnresolved{}
-					for _, unresolved := range validUnresolvedNames {
-						// Is this unresolved a package?
-						var theImport fileImport
-						if theImport, ok = file.imports[unresolved.name]; ok {
-							if theImport.inProject {
-								// There is a reference to an in-project package.
-
-								// This will be a reference like "package.ExportedMember"
-								// At the moment we only have "package", gather true name that is unresolved.
-								var packageName string = unresolved.name
-
-								// There should be a period.
-								var periodOffset int = unresolved.offset + len(unresolved.name)
-								if rune(fileText[periodOffset]) != '.' {
-									panic(`expected period when looking for unresolved reference`)
-								}
-
-								// Build out the rest of the name.
-								var unresolvedNameRunes []rune
-								var keepReading bool = true
-								for i := periodOffset + 1; keepReading; i++ {
-									var identifierRune rune
-									if identifierRune, keepReading = validIdentifierRune(fileText[i]); keepReading {
-										unresolvedNameRunes = append(unresolvedNameRunes, identifierRune)
-									}
-								}
-								var unresolvedName string = string(unresolvedNameRunes)
-
-								// If unresolved is ever empty we have an unexpected case.
-								if unresolvedName == "" {
-									panic(`unexpected empty name`)
-								}
-
-								// Add what we have.
-								unresolvedSet[packageName+"."+unresolvedName] = fileUnresolved{packageName: theImport.path, name: unresolvedName}
-
-							} else {
-								// Not in the project package.
-								// We know this is in the imports so no need to record it here.
-							}
-						} else {
-							// Not an import. This is part of this package.
-							unresolvedSet[folder.name+"."+unresolved.name] = fileUnresolved{packageName: folder.importPath, name: unresolved.name}
-						}
-					}
-
-					// Put them into the proper format.
-					for _, unresolved := range unresolvedSet {
-						file.unresolved = append(file.unresolved, unresolved)
-					}
-
-					folder.files = append(folder.files, file)
+			return packageFile{}, "", Error(err)
+		}
+
+		// Only continue with import paths that are in our projects.
+		// If this is in our projects we want to look for dependencies.
+		if inProject(importPath, projectPaths) {
+			switch {
+			case s.Name != nil && s.Name.Name == ".":
+				// The names of the package are used as though they were declared in this one.
+				file.dotImports = append(file.dotImports, importPath)
+			case s.Name != nil && s.Name.Name == "_":
+				// Only imported to be initialized.
+				blankImports = append(blankImports, importPath)
+			default:
+				var importName string = importPackageName(importPath)
+				if s.Name != nil {
+					importName = s.Name.Name
 				}
+				file.imports[importName] = fileImport{name: importName, path: importPath, inProject: true}
 			}
+		}
+	}
+
+	for _, object := range parsedFile.Scope.Objects {
+		file.declarations = append(file.declarations, fileDeclaration{kind: object.Kind.String(), name: object.Name, node: enclosingDeclaration(file.ranges, object.Pos())})
+	}
+	sort.Slice(file.declarations, func(i, j int) bool { return file.declarations[i].name < file.declarations[j].name })
+
+	// Methods are not in the package scope but other files still depend on them.
+	for _, decl := range parsedFile.Decls {
+		if funcDecl, ok := decl.(*ast.FuncDecl); ok && funcDecl.Recv != nil && len(funcDecl.Recv.List) > 0 {
+			receiverName := receiverTypeName(funcDecl.Recv.List[0].Type)
+			methodName := receiverName + "." + funcDecl.Name.Name
+			file.declarations = append(file.declarations, fileDeclaration{kind: DECLARATION_KIND_METHOD, name: methodName, node: methodName})
+		}
+	}
+
+	// Names selected from a struct or interface may be declared in it rather than as methods.
+	file.types = fileTypes(parsedFile, file.imports, folder.importPath)
+
+	// All unresolved.
+	var rawUnresolvedNames []unresolvedName
+	for _, unresolved := range parsedFile.Unresolved {
+		rawUnresolvedNames = append(rawUnresolvedNames, unresolvedName{
+			pos:  unresolved.NamePos,
+			name: unresolved.Name,
+		})
+	}
+	var qualified map[token.Pos]string = qualifiedNames(parsedFile)
+
+	// Collapse to an actually distinct set of accurate references.
+	// Predeclared names like len or error are only kept until we know if the package declares them itself.
+	var unresolvedSet map[string]fileUnresolved = map[string]fileUnresolved{}
+	addUnresolved := func(key string, unresolved fileUnresolved) {
+		// Each time the same reference is made counts towards the weight of the dependency.
+		unresolved.count = unresolvedSet[key].count + 1
+		unresolvedSet[key] = unresolved
+	}
+	for _, unresolved := range rawUnresolvedNames {
+		var from string = enclosingDeclaration(file.ranges, unresolved.pos)
+
+		// Is this unresolved a package?
+		var theImport fileImport
+		if theImport, ok = file.imports[unresolved.name]; ok {
+			if theImport.inProject {
+				// There is a reference to an in-project package.
+
+				// This will be a reference like "package.ExportedMember"
+				// At the moment we only have "package", gather true name that is unresolved.
+				var packageName string = unresolved.name
+
+				// There should be a selector. Whatever follows the name, like the type arguments
+				// of "package.Map[K, V]", is not part of it.
+				var unresolvedName string
+				if unresolvedName, ok = qualified[unresolved.pos]; !ok {
+					return packageFile{}, "", Error(newSourceError(fset, unresolved.pos, `expected selector when looking for unresolved reference`))
+				}
+
+				// Add what we have.
+				addUnresolved(from+":"+packageName+"."+unresolvedName, fileUnresolved{packageName: theImport.path, name: unresolvedName, from: from})
 
-			folders = append(folders, folder)
+			} else {
+				// Not in the project package.
+				// We know this is in the imports so no need to record it here.
+			}
+		} else {
+			// Not an import. This is part of this package, or any package imported with a dot.
+			addUnresolved(from+":"+folder.name+"."+unresolved.name, fileUnresolved{packageName: folder.importPath, name: unresolved.name, from: from})
+			// Only exported names come with a dot import.
+			if ast.IsExported(unresolved.name) {
+				for _, dotImport := range file.dotImports {
+					addUnresolved(from+":"+dotImport+"."+unresolved.name, fileUnresolved{packageName: dotImport, name: unresolved.name, from: from})
+				}
+			}
 		}
 	}
 
-	return folders, nil
+	// The parser resolves names declared in the same file itself, so they are never unresolved.
+	// Between the declarations of the file they are still dependencies.
+	ast.Inspect(parsedFile, func(node ast.Node) bool {
+		if ident, ok := node.(*ast.Ident); ok && ident.Obj != nil && parsedFile.Scope.Objects[ident.Name] == ident.Obj && ident.Pos() != ident.Obj.Pos() {
+			var from string = enclosingDeclaration(file.ranges, ident.Pos())
+			addUnresolved(from+":"+folder.name+"."+ident.Name, fileUnresolved{packageName: folder.importPath, name: ident.Name, from: from})
+		}
+		return true
+	})
+
+	// Initializing a package runs all of its files. No declaration makes the import.
+	for _, blankImport := range blankImports {
+		addUnresolved(":init:"+blankImport, fileUnresolved{packageName: blankImport, packageInit: true})
+	}
+
+	// A method is only linked when the type of the value it is selected from is declared in sight,
+	// as with a parameter or "x := &T{}". Otherwise only type checking can tell which method it is.
+	for _, selected := range typedSelectors(parsedFile) {
+		var from string = enclosingDeclaration(file.ranges, selected.pos)
+		var candidates []string
+		if selected.qualifier == "" {
+			candidates = append([]string{folder.importPath}, file.dotImports...)
+		} else if theImport, ok := file.imports[selected.qualifier]; ok && theImport.inProject {
+			candidates = []string{theImport.path}
+		}
+		for _, candidate := range candidates {
+			addUnresolved(from+":method:"+candidate+"."+selected.typeName+"."+selected.name, fileUnresolved{packageName: candidate, name: selected.name, receiver: selected.typeName, from: from})
+		}
+	}
+
+	// Put them into the proper format, in a fixed order.
+	var unresolvedKeys []string
+	for key := range unresolvedSet {
+		unresolvedKeys = append(unresolvedKeys, key)
+	}
+	sort.Strings(unresolvedKeys)
+	for _, key := range unresolvedKeys {
+		file.unresolved = append(file.unresolved, unresolvedSet[key])
+	}
+
+	return file, packageName, nil
+}
+
+// folderImportPath gets the import path a file is gathered under. External test packages, with their
+// "_test" package names, share a directory with the package they test but are a package of their own.
+func folderImportPath(importPath, packageName, filename string) (folderImportPath string) {
+	if strings.HasSuffix(packageName, "_test") && isTestFile(filename) {
+		return importPath + "_test"
+	}
+	return importPath
+}
+
+// dropUniverseNames drops the references to predeclared names, like len or error, that are not
+// declared again by the package referred to.
+func dropUniverseNames(folders []packageFolder) {
+
+	declared := map[string]map[string]bool{}
+	for _, folder := range folders {
+		if declared[folder.importPath] == nil {
+			declared[folder.importPath] = map[string]bool{}
+		}
+		for _, file := range folder.files {
+			for _, declaration := range file.declarations {
+				declared[folder.importPath][declaration.name] = true
+			}
+		}
+	}
+
+	for i, folder := range folders {
+		for j, file := range folder.files {
+			var kept []fileUnresolved
+			for _, unresolved := range file.unresolved {
+				if unresolved.receiver == "" && !unresolved.packageInit && types.Universe.Lookup(unresolved.name) != nil && !declared[unresolved.packageName][unresolved.name] {
+					continue
+				}
+				kept = append(kept, unresolved)
+			}
+			folders[i].files[j].unresolved = kept
+		}
+	}
+}
+
+// receiverTypeName gets the name of a method receiver's type, without pointers or type parameters.
+func receiverTypeName(expr ast.Expr) (name string) {
+	switch typed := expr.(type) {
+	case *ast.Ident:
+		return typed.Name
+	case *ast.StarExpr:
+		return receiverTypeName(typed.X)
+	case *ast.ParenExpr:
+		return receiverTypeName(typed.X)
+	case *ast.IndexExpr:
+		return receiverTypeName(typed.X)
+	case *ast.IndexListExpr:
+		return receiverTypeName(typed.X)
+	}
+	return ""
+}
+
+// fileTypes finds the structs and interfaces declared at the top level of a file, with their own members
+// and the project types they embed. Embedded types outside the project have nothing to depend on.
+func fileTypes(parsedFile *ast.File, imports map[string]fileImport, importPath string) (types []fileType) {
+
+	for _, decl := range parsedFile.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			typeSpec := spec.(*ast.TypeSpec)
+			var fields *ast.FieldList
+			switch typed := typeSpec.Type.(type) {
+			case *ast.StructType:
+				fields = typed.Fields
+			case *ast.InterfaceType:
+				fields = typed.Methods
+			default:
+				continue
+			}
+			fileType := fileType{name: typeSpec.Name.Name, members: map[string]bool{}}
+			for _, field := range fields.List {
+				for _, name := range field.Names {
+					fileType.members[name.Name] = true
+				}
+				if len(field.Names) > 0 {
+					continue
+				}
+				// An embedded type is also a member, by its own name.
+				qualifier, typeName := typeExprName(field.Type)
+				if typeName == "" {
+					continue
+				}
+				fileType.members[typeName] = true
+				if qualifier == "" {
+					fileType.embeds = append(fileType.embeds, typeReference{importPath: importPath, name: typeName})
+				} else if theImport, ok := imports[qualifier]; ok && theImport.inProject {
+					fileType.embeds = append(fileType.embeds, typeReference{importPath: theImport.path, name: typeName})
+				}
+			}
+			types = append(types, fileType)
+		}
+	}
+
+	return types
+}
+
+// typedSelector is a name selected from a value or type whose type is known from the file itself.
+type typedSelector struct {
+	pos       token.Pos // The position of the selected name.
+	qualifier string    // The package name the type is qualified by, blank for an unqualified type.
+	typeName  string    // The name of the type, without pointers or type arguments.
+	name      string    // The selected name.
+}
+
+// typedSelectors finds the names selected from values whose type is declared in sight, such as
+// a parameter, a variable with its type, a composite literal or the type itself. Selecting from
+// anything else, like the result of a call or a package level variable of another file, gives
+// no hint of the type and is left out.
+func typedSelectors(parsedFile *ast.File) (selectors []typedSelector) {
+
+	ast.Inspect(parsedFile, func(node ast.Node) bool {
+		selector, ok := node.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		// A package name is never declared in the file, so has no object.
+		ident, ok := selector.X.(*ast.Ident)
+		if !ok || ident.Obj == nil {
+			return true
+		}
+		if qualifier, typeName := typeExprName(objectType(ident)); typeName != "" {
+			selectors = append(selectors, typedSelector{pos: selector.Sel.Pos(), qualifier: qualifier, typeName: typeName, name: selector.Sel.Name})
+		}
+		return true
+	})
+
+	return selectors
 }
 
-func validIdentifierRune(b byte) (r rune, keepReading bool) {
-	switch rune(b) {
-	case 'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h', 'i', 'j', 'k', 'l', 'm', 'n', 'o', 'p', 'q', 'r', 's', 't', 'u', 'v', 'w', 'x', 'y', 'z':
-		return rune(b), true
-	case 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 'I', 'J', 'K', 'L', 'M', 'N', 'O', 'P', 'Q', 'R', 'S', 'T', 'U', 'V', 'W', 'X', 'Y', 'Z':
-		return rune(b), true
-	case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
-		return rune(b), true
-	case '_':
-		return rune(b), true
-	}
-	return rune(b), false
+// objectType gets the type expression of what an identifier is declared as, nil if it cannot be told
+// from the declaration.
+func objectType(ident *ast.Ident) (typeExpr ast.Expr) {
+	switch ident.Obj.Kind {
+	case ast.Typ:
+		// A method expression like "T.Method".
+		if _, ok := ident.Obj.Decl.(*ast.TypeSpec); ok {
+			return ident
+		}
+	case ast.Var:
+		switch decl := ident.Obj.Decl.(type) {
+		case *ast.Field:
+			return decl.Type
+		case *ast.ValueSpec:
+			if decl.Type != nil {
+				return decl.Type
+			}
+			for i, name := range decl.Names {
+				if name.Name == ident.Name && i < len(decl.Values) && len(decl.Names) == len(decl.Values) {
+					return valueType(decl.Values[i])
+				}
+			}
+		case *ast.AssignStmt:
+			if len(decl.Lhs) != len(decl.Rhs) {
+				return nil
+			}
+			for i, lhs := range decl.Lhs {
+				if name, ok := lhs.(*ast.Ident); ok && name.Name == ident.Name {
+					return valueType(decl.Rhs[i])
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// valueType gets the type of a value that says what it is, like "T{}", "&T{}" or "x.(T)".
+func valueType(value ast.Expr) (typeExpr ast.Expr) {
+	switch typed := value.(type) {
+	case *ast.CompositeLit:
+		return typed.Type
+	case *ast.UnaryExpr:
+		if typed.Op == token.AND {
+			return valueType(typed.X)
+		}
+	case *ast.ParenExpr:
+		return valueType(typed.X)
+	case *ast.TypeAssertExpr:
+		return typed.Type
+	}
+	return nil
+}
+
+// typeExprName gets the name of a named type, and the package name qualifying it, without pointers or
+// type arguments. The name is blank for any other type.
+func typeExprName(typeExpr ast.Expr) (qualifier, typeName string) {
+	switch typed := typeExpr.(type) {
+	case *ast.Ident:
+		return "", typed.Name
+	case *ast.SelectorExpr:
+		if packageIdent, ok := typed.X.(*ast.Ident); ok {
+			return packageIdent.Name, typed.Sel.Name
+		}
+	case *ast.StarExpr:
+		return typeExprName(typed.X)
+	case *ast.ParenExpr:
+		return typeExprName(typed.X)
+	case *ast.IndexExpr:
+		return typeExprName(typed.X)
+	case *ast.IndexListExpr:
+		return typeExprName(typed.X)
+	}
+	return "", ""
+}
+
+// qualifiedNames finds the name selected after each package name in a file, by the position of the package name.
+func qualifiedNames(parsedFile *ast.File) (names map[token.Pos]string) {
+
+	names = map[token.Pos]string{}
+	ast.Inspect(parsedFile, func(node ast.Node) bool {
+		if selector, ok := node.(*ast.SelectorExpr); ok {
+			// A package name is never declared in the file, so it is unresolved.
+			if ident, ok := selector.X.(*ast.Ident); ok && ident.Obj == nil {
+				names[ident.Pos()] = selector.Sel.Name
+			}
+		}
+		return true
+	})
+
+	return names
+}
+
+// importPackageName guesses the name of a package from its import path.
+func importPackageName(importPath string) (name string) {
+	name = path.Base(importPath)
+	// Major versions are not part of the name. For example "example.com/thing/v2" is package "thing".
+	if len(name) > 1 && name[0] == 'v' && strings.Trim(name[1:], "0123456789") == "" {
+		name = path.Base(path.Dir(importPath))
+	}
+	// Dotted versions are not part of the name. For example "gopkg.in/check.v1" is package "check".
+	if i := strings.Index(name, "."); i > 0 {
+		name = name[:i]
+	}
+	return name
 }
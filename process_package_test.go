@@ -0,0 +1,127 @@
+package technical_debt
+
+import (
+	. "gopkg.in/check.v1" // https://labix.org/gocheck
+
+	"fmt"
+	"path/filepath"
+	"sort"
+)
+
+// Create a suite.
+type ProcessPackageSuite struct{}
+
+var _ = Suite(&ProcessPackageSuite{})
+
+// Add the tests.
+
+func (s *ProcessPackageSuite) Test_ConcurrencyIsDeterministic(c *C) {
+	modules, err := LoadModules("testdata/methods")
+	c.Assert(err, IsNil)
+	packages, err := PackagePaths(modules, Config{})
+	c.Assert(err, IsNil)
+
+	// The files are always in the same order, whatever the number of workers.
+	var expected string
+	for _, concurrency := range []int{1, 2, 8} {
+		for _, resolver := range []string{RESOLVER_SYNTAX, RESOLVER_TYPES} {
+			comment := Commentf("Concurrency: %v Resolver: %v", concurrency, resolver)
+			folders, _, err := ProcessPackage(Config{Concurrency: concurrency, Resolver: resolver}, packages, []string{"example.com/methods"})
+			c.Assert(err, IsNil, comment)
+			c.Assert(len(folders), Equals, 1, comment)
+			c.Check(folders[0].files[0].name, Equals, "box.go", comment)
+			c.Check(folders[0].files[9].name, Equals, "use.go", comment)
+
+			if resolver == RESOLVER_SYNTAX {
+				if expected == "" {
+					expected = fmt.Sprint(folders)
+				}
+				c.Check(fmt.Sprint(folders), Equals, expected, comment)
+			}
+		}
+	}
+}
+
+func (s *ProcessPackageSuite) Test_ParseErrors(c *C) {
+	root := c.MkDir()
+	writeTestFile(c, filepath.Join(root, "go.mod"), "module example.com/broken\n")
+	writeTestFile(c, filepath.Join(root, "good.go"), "package broken\n\ntype Good struct{}\n")
+	writeTestFile(c, filepath.Join(root, "broken.go"), "package broken\n\nfunc Broken() Good {\n\treturn Good{}\n}\n\nfunc Worse( {\n}\n")
+	writeTestFile(c, filepath.Join(root, "hopeless.go"), "packag broken\n")
+
+	tests := []struct {
+		parseErrors string
+		codeFiles   []string
+		dependsOn   []string
+		errorMsg    string
+	}{
+		{
+			parseErrors: PARSE_ERRORS_ABORT,
+			errorMsg:    `.*broken.go:7:13: expected '\)', found '{'(?s).*`,
+		},
+		{
+			parseErrors: PARSE_ERRORS_EXCLUDE,
+			codeFiles:   []string{"example.com/broken/good.go"},
+		},
+		{
+			// The broken file keeps what could be parsed, but hopeless files are still left out.
+			parseErrors: PARSE_ERRORS_PARTIAL,
+			codeFiles:   []string{"example.com/broken/broken.go", "example.com/broken/good.go"},
+			dependsOn:   []string{"example.com/broken/good.go"},
+		},
+	}
+	for i, test := range tests {
+		comment := Commentf("Case %v: %v", i, test)
+		config := Config{ModuleRoot: root, ParseErrors: test.parseErrors}
+		modules, err := config.Modules()
+		c.Assert(err, IsNil, comment)
+		packages, err := PackagePaths(modules, Config{})
+		c.Assert(err, IsNil, comment)
+		folders, diagnostics, err := ProcessPackage(config, packages, config.ProjectPaths(modules))
+		if test.errorMsg != "" {
+			c.Check(err, ErrorMatches, test.errorMsg, comment)
+			continue
+		}
+		c.Assert(err, IsNil, comment)
+
+		// Every problem is a diagnostic, the parser finds several in the broken file.
+		firstMessages := map[string]string{}
+		for _, diagnostic := range diagnostics {
+			filename := filepath.Base(diagnostic.Filename)
+			if _, ok := firstMessages[filename]; !ok {
+				firstMessages[filename] = fmt.Sprintf("%d: %s", diagnostic.Line, diagnostic.Message)
+			}
+		}
+		c.Check(firstMessages, DeepEquals, map[string]string{
+			"broken.go":   "7: expected ')', found '{'",
+			"hopeless.go": "1: expected 'package', found packag",
+		}, comment)
+
+		codeFiles := CreateCodeFiles(folders, Config{})
+		var names []string
+		for name := range codeFiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		c.Check(names, DeepEquals, test.codeFiles, comment)
+		c.Check(dependsOnNames(codeFiles["example.com/broken/broken.go"]), DeepEquals, test.dependsOn, comment)
+	}
+}
+
+func (s *ProcessPackageSuite) Test_ExternalTestPackages(c *C) {
+	for _, resolver := range []string{RESOLVER_SYNTAX, RESOLVER_TYPES} {
+		comment := Commentf("Resolver: %v", resolver)
+		codeFiles := fixtureCodeFiles(c, "testdata/xtest", Config{IncludeTests: true, Resolver: resolver})
+
+		// The package under test is not confused by the names in the external test package.
+		c.Check(dependsOnNames(codeFiles["example.com/xtest/lib/helper_test.go"]), DeepEquals, []string{
+			"example.com/xtest/lib/lib.go",
+		}, comment)
+		c.Check(dependsOnNames(codeFiles["example.com/xtest/lib_test/lib_test.go"]), DeepEquals, []string{
+			"example.com/xtest/lib/lib.go",
+			"example.com/xtest/lib_test/util_test.go",
+		}, comment)
+		c.Check(dependsOnNames(codeFiles["example.com/xtest/lib_test/util_test.go"]), IsNil, comment)
+		c.Check(len(codeFiles), Equals, 4, comment)
+	}
+}
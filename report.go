@@ -0,0 +1,249 @@
+package technical_debt
+
+import (
+	"sort"
+)
+
+const (
+	REPORT_VERSION = 1 // Changes whenever the report changes in a way that could break a reader.
+)
+
+const (
+	OUTPUT_GRID    = "grid"    // The svg grid of dependencies.
+	OUTPUT_JSON    = "json"    // The json report.
+	OUTPUT_CSV     = "csv"     // The spreadsheet of code file metrics, comma separated.
+	OUTPUT_TSV     = "tsv"     // The spreadsheet of code file metrics, tab separated.
+	OUTPUT_DOT     = "dot"     // The direct dependency graph and its condensation for graphviz.
+	OUTPUT_GRAPHML = "graphml" // The direct dependency graph and its condensation as graphml.
+	OUTPUT_HTML    = "html"    // The interactive grid, a single html file that works offline.
+)
+
+// Report is everything learned from an analysis, in a form to be written out for other tools.
+// The schema is root/schema/report.schema.json.
+type Report struct {
+	Version     int
+	Granularity string
+	Tests       string
+	Prefix      string // The longest prefix shared by node names.
+	Thresholds  ReportThresholds
+	Metrics     ReportMetrics
+	WithTests   *ReportMetrics `json:",omitempty"` // The metrics including tests, only for the test overlay.
+	Files       []ReportFile   // In grid order.
+	Groups      []ReportGroup  // In grid order.
+	Partitions  []ReportPartition
+	Breakdowns  []Breakdown   `json:",omitempty"`
+	Diagnostics []SourceError // The problems in the source that were tolerated.
+}
+
+// ReportThresholds are what divided the code files into partitions.
+type ReportThresholds struct {
+	View             string
+	VisibilityFanIn  int
+	VisibilityFanOut int
+}
+
+// ReportMetrics are the metrics of the whole dependency graph.
+type ReportMetrics struct {
+	PropogationCost float64
+	DirectDensity   float64
+	CoreCount       int
+	FileCount       int
+	MaxReferences   int
+	Distances       *Distances `json:",omitempty"` // Only if measured.
+}
+
+// ReportFile is a single node of the dependency graph.
+type ReportFile struct {
+	Name                string
+	Package             string
+	Index               int // The position in the grid.
+	Partition           string
+	CyclicFingerprint   string // Identifies the cyclical group.
+	Component           int    // The index of the cyclical group in the condensation.
+	GroupSize           int    // The code files in the cyclical group.
+	VisibilityFanIn     int
+	VisibilityFanOut    int
+	DirectDependsOn     []string              // Sorted.
+	DirectTestDependsOn []string              // Sorted, the direct dependencies only from tests.
+	DependsOn           []string              // Sorted, every code file reached including this one.
+	TestDependsOn       []string              // Sorted, the deep dependencies only from tests.
+	Weights             map[string]EdgeWeight // The weight of each direct dependency.
+	Test                bool
+	Generated           bool
+	TestFanIn           int
+}
+
+// ReportGroup is a cyclical group of code files.
+type ReportGroup struct {
+	CyclicFingerprint string
+	Component         int
+	Partition         string
+	FileCount         int
+	VisibilityFanIn   int
+	VisibilityFanOut  int
+	Files             []string // In grid order.
+	InternalEdges     []Edge
+}
+
+// ReportPartition is a partition of the grid.
+type ReportPartition struct {
+	Name         string
+	LowestIndex  int
+	HighestIndex int
+	FileCount    int
+	Groups       []string // The fingerprints of the cyclical groups, in grid order.
+}
+
+// CreateReport gathers the analysis into a report. With the test overlay, the production analysis
+// has the main metrics. Otherwise the production analysis is the analysis. Breakdowns are optional,
+// and the diagnostics are those from processing the packages.
+func CreateReport(config Config, analysis, production Analysis, breakdowns []Breakdown, diagnostics []SourceError) (report Report) {
+
+	report = Report{
+		Version:     REPORT_VERSION,
+		Granularity: config.Granularity,
+		Tests:       config.TestMode(),
+		Prefix:      analysis.Prefix,
+		Thresholds: ReportThresholds{
+			View:             config.View,
+			VisibilityFanIn:  analysis.VisibilityFanIn,
+			VisibilityFanOut: analysis.VisibilityFanOut,
+		},
+		Metrics:     reportMetrics(production),
+		Breakdowns:  breakdowns,
+		Diagnostics: append([]SourceError{}, diagnostics...),
+	}
+	if report.Granularity == "" {
+		report.Granularity = GRANULARITY_FILE
+	}
+	if report.Tests == TESTS_OVERLAY {
+		withTests := reportMetrics(analysis)
+		report.WithTests = &withTests
+	}
+
+	// The partitions hold the code files in grid order. Lists and maps are never nil, so a reader
+	// always finds a list or object rather than null.
+	for _, partition := range analysis.Partitions {
+		reportPartition := ReportPartition{
+			Name:         partition.Name,
+			LowestIndex:  partition.LowestIndex,
+			HighestIndex: partition.HighestIndex,
+			FileCount:    partition.FileCount,
+			Groups:       []string{},
+		}
+		for _, group := range partition.Groups {
+			reportPartition.Groups = append(reportPartition.Groups, group.CyclicFingerprint)
+			reportGroup := ReportGroup{
+				CyclicFingerprint: group.CyclicFingerprint,
+				Component:         group.Component,
+				Partition:         partition.Name,
+				FileCount:         group.FileCount,
+				VisibilityFanIn:   group.VisibilityFanIn,
+				VisibilityFanOut:  group.VisibilityFanOut,
+				Files:             []string{},
+				InternalEdges:     append([]Edge{}, group.InternalEdges...),
+			}
+			for _, file := range group.Files {
+				reportGroup.Files = append(reportGroup.Files, file.Name)
+
+				// The analyzed code files have the dependencies, the partitions have the index.
+				codeFile := analysis.CodeFiles[file.Name]
+				weights := map[string]EdgeWeight{}
+				for dependsOnName, weight := range codeFile.Weights {
+					weights[dependsOnName] = weight
+				}
+				report.Files = append(report.Files, ReportFile{
+					Name:                codeFile.Name,
+					Package:             nodePackage(codeFile.Name, report.Granularity),
+					Index:               file.Index,
+					Partition:           partition.Name,
+					CyclicFingerprint:   codeFile.CyclicFingerprint,
+					Component:           codeFile.Component,
+					GroupSize:           group.FileCount,
+					VisibilityFanIn:     codeFile.VisibilityFanIn,
+					VisibilityFanOut:    codeFile.VisibilityFanOut,
+					DirectDependsOn:     sortedNames(codeFile.DependsOn),
+					DirectTestDependsOn: directTestDependsOn(analysis, production, codeFile.Name),
+					DependsOn:           analysis.Closure.Reached(codeFile.Name),
+					TestDependsOn:       testDependsOn(analysis, codeFile.Name),
+					Weights:             weights,
+					Test:                codeFile.Test,
+					Generated:           codeFile.Generated,
+					TestFanIn:           codeFile.TestFanIn,
+				})
+			}
+			report.Groups = append(report.Groups, reportGroup)
+		}
+		report.Partitions = append(report.Partitions, reportPartition)
+	}
+
+	return report
+}
+
+// reportMetrics gets the metrics of the whole dependency graph.
+func reportMetrics(analysis Analysis) (metrics ReportMetrics) {
+	metrics = ReportMetrics{
+		PropogationCost: analysis.PropogationCost,
+		DirectDensity:   analysis.DirectDensity,
+		CoreCount:       analysis.CoreCount,
+		FileCount:       analysis.FileCount,
+		MaxReferences:   analysis.MaxReferences,
+	}
+	if analysis.Distances.Histogram != nil {
+		distances := analysis.Distances
+		metrics.Distances = &distances
+	}
+	return metrics
+}
+
+// directTestDependsOn lists the sorted code files a code file only depends on directly once tests are included.
+func directTestDependsOn(analysis, production Analysis, name string) (names []string) {
+	names = []string{}
+	if analysis.Production == nil {
+		return names
+	}
+	for dependsOnName := range analysis.CodeFiles[name].DependsOn {
+		if !production.CodeFiles[name].DependsOn[dependsOnName] {
+			names = append(names, dependsOnName)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WithoutDeepDependencies gets a copy of the report without the deep dependencies of each code file, which
+// grow with the square of the code files. They follow from the direct dependencies.
+func (r Report) WithoutDeepDependencies() (report Report) {
+	report = r
+	report.Files = make([]ReportFile, len(r.Files))
+	for i, file := range r.Files {
+		file.DependsOn = []string{}
+		file.TestDependsOn = []string{}
+		report.Files[i] = file
+	}
+	return report
+}
+
+// testDependsOn lists the sorted code files a code file only reaches once tests are included.
+func testDependsOn(analysis Analysis, name string) (names []string) {
+	names = []string{}
+	if analysis.Production == nil {
+		return names
+	}
+	for _, reachedName := range analysis.Closure.Reached(name) {
+		if !analysis.Production.Reaches(name, reachedName) {
+			names = append(names, reachedName)
+		}
+	}
+	return names
+}
+
+// sortedNames lists a set of names in order.
+func sortedNames(set map[string]bool) (names []string) {
+	names = []string{}
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,293 @@
+package technical_debt
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+
+	. "gopkg.in/check.v1" // https://labix.org/gocheck
+)
+
+// Create a suite.
+type ReportSuite struct{}
+
+var _ = Suite(&ReportSuite{})
+
+// Add the tests.
+
+func (s *ReportSuite) Test_CreateReport(c *C) {
+	codeFiles := testCodeFiles(map[string][]string{
+		"r/a/one.go":   {"r/a/two.go"},
+		"r/a/two.go":   {"r/a/one.go", "r/b/three.go"},
+		"r/b/three.go": {},
+	})
+	codeFiles["r/a/one.go"].Weights["r/a/two.go"] = EdgeWeight{Symbols: 1, References: 3}
+	analysis, err := Analyze(codeFiles, VIEW_MEDIAN)
+	c.Assert(err, IsNil)
+
+	report := CreateReport(Config{View: VIEW_MEDIAN}, analysis, analysis, nil, nil)
+	c.Check(report.Version, Equals, REPORT_VERSION)
+	c.Check(report.Granularity, Equals, GRANULARITY_FILE)
+	c.Check(report.Tests, Equals, TESTS_EXCLUDE)
+	c.Check(report.Prefix, Equals, "r")
+	c.Check(report.Thresholds, Equals, ReportThresholds{View: VIEW_MEDIAN, VisibilityFanIn: analysis.VisibilityFanIn, VisibilityFanOut: analysis.VisibilityFanOut})
+	c.Check(report.Metrics, DeepEquals, ReportMetrics{PropogationCost: 7.0 / 9.0, DirectDensity: 3.0 / 9.0, CoreCount: 2, FileCount: 3, MaxReferences: 3})
+	c.Check(report.WithTests, IsNil)
+	c.Check(report.Diagnostics, DeepEquals, []SourceError{})
+
+	// Every code file is in grid order, with its cyclical group and partition.
+	c.Assert(len(report.Files), Equals, 3)
+	for i, file := range report.Files {
+		c.Check(file.Index, Equals, i)
+	}
+	var one ReportFile
+	for _, file := range report.Files {
+		if file.Name == "r/a/one.go" {
+			one = file
+		}
+	}
+	c.Check(one.Package, Equals, "r/a")
+	c.Check(one.CyclicFingerprint, Equals, "r/a/one.go")
+	c.Check(one.GroupSize, Equals, 2)
+	c.Check(one.DirectDependsOn, DeepEquals, []string{"r/a/two.go"})
+	c.Check(one.DependsOn, DeepEquals, []string{"r/a/one.go", "r/a/two.go", "r/b/three.go"})
+	c.Check(one.TestDependsOn, DeepEquals, []string{})
+	c.Check(one.Weights, DeepEquals, map[string]EdgeWeight{"r/a/two.go": {Symbols: 1, References: 3}})
+	c.Check(one.VisibilityFanOut, Equals, 3)
+
+	// The groups and partitions name each other.
+	c.Assert(len(report.Groups), Equals, 2)
+	fileCount := 0
+	for _, partition := range report.Partitions {
+		fileCount += partition.FileCount
+		for _, fingerprint := range partition.Groups {
+			found := false
+			for _, group := range report.Groups {
+				if group.CyclicFingerprint == fingerprint {
+					found = true
+					c.Check(group.Partition, Equals, partition.Name)
+				}
+			}
+			c.Check(found, Equals, true)
+		}
+	}
+	c.Check(fileCount, Equals, 3)
+}
+
+func (s *ReportSuite) Test_CreateReportOverlay(c *C) {
+	codeFiles := testCodeFiles(map[string][]string{
+		"a.go":      {},
+		"a_test.go": {"a.go"},
+	})
+	codeFiles["a_test.go"] = CodeFile{Name: "a_test.go", Test: true, DependsOn: map[string]bool{}, TestDependsOn: map[string]bool{"a.go": true}, Weights: map[string]EdgeWeight{}}
+	analysis, err := Analyze(WithTests(codeFiles), VIEW_MEDIAN)
+	c.Assert(err, IsNil)
+	production, err := Analyze(WithoutTests(codeFiles), VIEW_MEDIAN)
+	c.Assert(err, IsNil)
+	OverlayTests(&analysis, production)
+	analysis.Distances = CalculateDistances(analysis, DEFAULT_DECAY)
+
+	report := CreateReport(Config{View: VIEW_MEDIAN, Tests: TESTS_OVERLAY}, analysis, production, nil, nil)
+	c.Check(report.Metrics.FileCount, Equals, 1)
+	c.Check(report.Metrics.Distances, IsNil)
+	c.Assert(report.WithTests, NotNil)
+	c.Check(report.WithTests.FileCount, Equals, 2)
+	c.Check(report.WithTests.Distances, NotNil)
+
+	// The viewer follows the direct dependencies, knowing which are only from tests, in place of the deep ones.
+	for _, file := range report.Files {
+		if file.Name == "a_test.go" {
+			c.Check(file.DirectTestDependsOn, DeepEquals, []string{"a.go"})
+			c.Check(file.TestDependsOn, DeepEquals, []string{"a.go", "a_test.go"})
+		} else {
+			c.Check(file.DirectTestDependsOn, DeepEquals, []string{})
+		}
+	}
+	viewed := report.WithoutDeepDependencies()
+	for i, file := range viewed.Files {
+		c.Check(file.DependsOn, DeepEquals, []string{})
+		c.Check(file.TestDependsOn, DeepEquals, []string{})
+		c.Check(file.DirectDependsOn, DeepEquals, report.Files[i].DirectDependsOn)
+	}
+	c.Check(len(report.Files[0].DependsOn) > 0, Equals, true)
+}
+
+func (s *ReportSuite) Test_ReportSchema(c *C) {
+	data, err := ioutil.ReadFile("root/schema/report.schema.json")
+	c.Assert(err, IsNil)
+	var schema map[string]interface{}
+	c.Assert(json.Unmarshal(data, &schema), IsNil)
+
+	// A report with everything optional present.
+	codeFiles := testCodeFiles(map[string][]string{
+		"r/a/one.go":   {"r/a/two.go"},
+		"r/a/two.go":   {"r/a/one.go", "r/b/three.go"},
+		"r/b/three.go": {},
+	})
+	codeFiles["r/a/one.go"].Weights["r/a/two.go"] = EdgeWeight{Symbols: 1, References: 1}
+	analysis, err := Analyze(codeFiles, VIEW_MEDIAN)
+	c.Assert(err, IsNil)
+	analysis.Distances = CalculateDistances(analysis, DEFAULT_DECAY)
+	breakdowns, err := CreateBreakdowns(analysis, GRANULARITY_FILE)
+	c.Assert(err, IsNil)
+	diagnostics := []SourceError{{Filename: "r/a/broken.go", Line: 7, Column: 13, Message: "expected ')', found '{'"}}
+	report := CreateReport(Config{View: VIEW_MEDIAN, Tests: TESTS_OVERLAY}, analysis, analysis, breakdowns, diagnostics)
+	data, err = json.Marshal(report)
+	c.Assert(err, IsNil)
+	var value interface{}
+	c.Assert(json.Unmarshal(data, &value), IsNil)
+
+	// Every object in the report has exactly the properties of the schema, each of the right type.
+	c.Check(schemaMismatches(schema, schema, value, "report"), IsNil)
+
+	// Values of the wrong type or outside their enum are found too.
+	tests := []struct {
+		path       []string
+		value      interface{}
+		mismatches []string
+	}{
+		{path: []string{"Version"}, value: "1", mismatches: []string{"report.Version is not 1"}},
+		{path: []string{"Tests"}, value: "sometimes", mismatches: []string{"report.Tests is not one of exclude,include,overlay"}},
+		{path: []string{"Prefix"}, value: 1.0, mismatches: []string{"report.Prefix is not a string"}},
+		{path: []string{"Metrics", "CoreCount"}, value: 1.5, mismatches: []string{"report.Metrics.CoreCount is not an integer"}},
+		{path: []string{"Metrics", "PropogationCost"}, value: 2.0, mismatches: []string{"report.Metrics.PropogationCost is above 1"}},
+		{path: []string{"Files"}, value: map[string]interface{}{}, mismatches: []string{"report.Files is not an array"}},
+		{path: []string{"Diagnostics"}, value: []interface{}{map[string]interface{}{"Filename": "a.go", "Line": -1.0, "Column": 1.0, "Message": "m"}}, mismatches: []string{"report.Diagnostics[].Line is below 0"}},
+	}
+	for i, test := range tests {
+		comment := Commentf("Case %v: %v", i, test)
+		var broken map[string]interface{}
+		c.Assert(json.Unmarshal(data, &broken), IsNil, comment)
+		object := broken
+		for _, key := range test.path[:len(test.path)-1] {
+			object = object[key].(map[string]interface{})
+		}
+		object[test.path[len(test.path)-1]] = test.value
+		c.Check(schemaMismatches(schema, schema, broken, "report"), DeepEquals, test.mismatches, comment)
+	}
+}
+
+func (s *ReportSuite) Test_ViewerTemplate(c *C) {
+	codeFiles := testCodeFiles(map[string][]string{
+		"r/a/one.go":          {"r/a/</script>two.go"},
+		"r/a/</script>two.go": {},
+	})
+	analysis, err := Analyze(codeFiles, VIEW_MEDIAN)
+	c.Assert(err, IsNil)
+	data, err := json.Marshal(CreateReport(Config{View: VIEW_MEDIAN}, analysis, analysis, nil, nil))
+	c.Assert(err, IsNil)
+
+	// The report is placed in the viewer as javascript, and no name can end the script early.
+	t, err := template.ParseFiles("root/template/viewer.template")
+	c.Assert(err, IsNil)
+	var output strings.Builder
+	c.Assert(t.Execute(&output, struct {
+		Title  string
+		Report string
+	}{Title: "<r>", Report: string(data)}), IsNil)
+	c.Check(strings.Contains(output.String(), "<title>&lt;r&gt;</title>"), Equals, true)
+	c.Check(strings.Contains(output.String(), "const report = {\"Version\":1,"), Equals, true)
+	c.Check(strings.Count(output.String(), "</script>"), Equals, 1)
+}
+
+// schemaMismatches finds where a decoded json value does not match its schema, with different object keys,
+// a different type, a value outside its enum or bounds.
+func schemaMismatches(root, schema map[string]interface{}, value interface{}, at string) (mismatches []string) {
+	if ref, ok := schema["$ref"].(string); ok {
+		definition := root
+		for _, key := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+			definition = definition[key].(map[string]interface{})
+		}
+		return schemaMismatches(root, definition, value, at)
+	}
+	if constant, ok := schema["const"]; ok && !reflect.DeepEqual(value, constant) {
+		return []string{fmt.Sprintf("%s is not %v", at, constant)}
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		var options []string
+		for _, option := range enum {
+			found = found || reflect.DeepEqual(value, option)
+			options = append(options, fmt.Sprint(option))
+		}
+		if !found {
+			return []string{at + " is not one of " + strings.Join(options, ",")}
+		}
+	}
+	if schemaType, ok := schema["type"].(string); ok && !schemaTypeMatches(schemaType, value) {
+		article := "a "
+		if strings.ContainsAny(schemaType[:1], "aeiou") {
+			article = "an "
+		}
+		return []string{at + " is not " + article + schemaType}
+	}
+	if number, ok := value.(float64); ok {
+		if minimum, ok := schema["minimum"].(float64); ok && number < minimum {
+			mismatches = append(mismatches, fmt.Sprintf("%s is below %v", at, minimum))
+		}
+		if maximum, ok := schema["maximum"].(float64); ok && number > maximum {
+			mismatches = append(mismatches, fmt.Sprintf("%s is above %v", at, maximum))
+		}
+	}
+	switch value := value.(type) {
+	case map[string]interface{}:
+		if properties, ok := schema["properties"].(map[string]interface{}); ok {
+			var want, got []string
+			for key := range properties {
+				want = append(want, key)
+			}
+			for key := range value {
+				got = append(got, key)
+				if property, ok := properties[key].(map[string]interface{}); ok {
+					mismatches = append(mismatches, schemaMismatches(root, property, value[key], at+"."+key)...)
+				}
+			}
+			sort.Strings(want)
+			sort.Strings(got)
+			if strings.Join(want, ",") != strings.Join(got, ",") {
+				mismatches = append(mismatches, at+" has "+strings.Join(got, ",")+" not "+strings.Join(want, ","))
+			}
+		} else if additional, ok := schema["additionalProperties"].(map[string]interface{}); ok {
+			for key, element := range value {
+				mismatches = append(mismatches, schemaMismatches(root, additional, element, at+"."+key)...)
+			}
+		}
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for _, element := range value {
+				mismatches = append(mismatches, schemaMismatches(root, items, element, at+"[]")...)
+			}
+		}
+	}
+	return mismatches
+}
+
+// schemaTypeMatches reports whether a decoded json value is of a schema type.
+func schemaTypeMatches(schemaType string, value interface{}) bool {
+	switch schemaType {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		number, ok := value.(float64)
+		return ok && number == math.Trunc(number)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	}
+	return value == nil
+}
@@ -0,0 +1,159 @@
+package technical_debt
+
+import (
+	. "gopkg.in/check.v1" // https://labix.org/gocheck
+)
+
+// Create a suite.
+type ResolverSuite struct{}
+
+var _ = Suite(&ResolverSuite{})
+
+// Add the tests.
+
+func (s *ResolverSuite) Test_Resolvers(c *C) {
+	tests := []struct {
+		resolver  string
+		dependsOn []string
+	}{
+		{
+			// Syntax sees the qualified reference and the method name, but not the embedding.
+			resolver: RESOLVER_SYNTAX,
+			dependsOn: []string{
+				"example.com/resolve/shapes/base_methods.go",
+				"example.com/resolve/shapes/shape.go",
+			},
+		},
+		{
+			// Type checking follows the promoted method through the embedded field.
+			resolver: RESOLVER_TYPES,
+			dependsOn: []string{
+				"example.com/resolve/shapes/base.go",
+				"example.com/resolve/shapes/base_methods.go",
+				"example.com/resolve/shapes/shape.go",
+			},
+		},
+	}
+	for i, test := range tests {
+		comment := Commentf("Case %v: %v", i, test)
+		codeFiles := fixtureCodeFiles(c, "testdata/resolve", Config{Resolver: test.resolver})
+		c.Check(dependsOnNames(codeFiles["example.com/resolve/app/app.go"]), DeepEquals, test.dependsOn, comment)
+	}
+}
+
+func (s *ResolverSuite) Test_MethodDeclarations(c *C) {
+	byResolver := resolverCodeFiles(c, "testdata/methods", Config{})
+
+	tests := []dependsOnTest{
+		{
+			codeFile: "example.com/methods/things/use.go",
+			dependsOn: []string{
+				"example.com/methods/things/list.go",
+				"example.com/methods/things/list_push.go",
+				"example.com/methods/things/thing.go",
+				"example.com/methods/things/thing_do.go",
+			},
+		},
+		{
+			codeFile:  "example.com/methods/things/list_push.go",
+			dependsOn: []string{"example.com/methods/things/list.go"},
+		},
+		{
+			// A method of a type outside the project is not any project method of the same name.
+			codeFile:  "example.com/methods/things/describe.go",
+			dependsOn: nil,
+		},
+		{
+			// A field is part of its struct, not a method of the same name on another type.
+			codeFile:  "example.com/methods/things/box.go",
+			dependsOn: nil,
+		},
+		{
+			// A promoted method is only looked for on the types embedded.
+			codeFile:  "example.com/methods/things/pallet.go",
+			dependsOn: []string{"example.com/methods/things/crate.go"},
+		},
+	}
+	checkDependsOn(c, byResolver, tests)
+}
+
+func (s *ResolverSuite) Test_Imports(c *C) {
+	byResolver := resolverCodeFiles(c, "testdata/imports", Config{})
+
+	tests := []dependsOnTest{
+		{
+			// Dot imported names are found in the imported package, not this one.
+			codeFile: "example.com/imports/paint/paint.go",
+			dependsOn: []string{
+				"example.com/imports/colors/colors.go",
+				"example.com/imports/shade/v2/shade.go",
+			},
+		},
+		{
+			codeFile:  "example.com/imports/paint/use.go",
+			dependsOn: []string{"example.com/imports/colors/palette.go"},
+		},
+		{
+			codeFile:  "example.com/imports/paint/alias.go",
+			dependsOn: []string{"example.com/imports/colors/colors.go"},
+		},
+		{
+			// A blank import depends on every file of the package being initialized.
+			codeFile: "example.com/imports/plugins/plugins.go",
+			dependsOn: []string{
+				"example.com/imports/colors/colors.go",
+				"example.com/imports/colors/palette.go",
+				"example.com/imports/colors/register.go",
+			},
+		},
+	}
+	checkDependsOn(c, byResolver, tests)
+}
+
+func (s *ResolverSuite) Test_UniverseNames(c *C) {
+	byResolver := resolverCodeFiles(c, "testdata/universe", Config{})
+
+	// A package declaring a predeclared name replaces it, but only for itself.
+	tests := []dependsOnTest{
+		{
+			codeFile:  "example.com/universe/calc/use.go",
+			dependsOn: []string{"example.com/universe/calc/calc.go"},
+		},
+		{
+			codeFile:  "example.com/universe/calc/bytes.go",
+			dependsOn: nil,
+		},
+		{
+			codeFile:  "example.com/universe/other/other.go",
+			dependsOn: []string{"example.com/universe/calc/use.go"},
+		},
+	}
+	checkDependsOn(c, byResolver, tests)
+}
+
+func (s *ResolverSuite) Test_UniverseNamesDropped(c *C) {
+	config := Config{ModuleRoot: "testdata/universe"}
+	modules, err := config.Modules()
+	c.Assert(err, IsNil)
+	packages, err := PackagePaths(modules, config)
+	c.Assert(err, IsNil)
+	folders, _, err := ProcessPackage(config, packages, config.ProjectPaths(modules))
+	c.Assert(err, IsNil)
+
+	// Only the declared min is left of the predeclared names. Other names may be in either package.
+	var names []string
+	for _, folder := range folders {
+		for _, file := range folder.files {
+			for _, unresolved := range file.unresolved {
+				if unresolved.receiver == "" {
+					names = append(names, file.name+" "+unresolved.packageName+"."+unresolved.name)
+				}
+			}
+		}
+	}
+	c.Check(names, DeepEquals, []string{
+		"use.go example.com/universe/calc.min",
+		"other.go example.com/universe/calc.Use",
+		"other.go example.com/universe/other.Use",
+	})
+}
@@ -0,0 +1,61 @@
+package technical_debt
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+// spreadsheetHeader names the columns of the spreadsheet, one row for each code file.
+var spreadsheetHeader = []string{
+	"package",
+	"file",
+	"visibility fan in",
+	"visibility fan out",
+	"partition",
+	"cyclic group size",
+	"direct fan in",
+	"direct fan out",
+	"lines of code",
+}
+
+// WriteSpreadsheet writes a row of metrics for every analyzed code file in grid order, separated by
+// the comma, such as ',' for csv or '\t' for tsv.
+func WriteSpreadsheet(w io.Writer, analysis Analysis, granularity string, comma rune) (err error) {
+
+	writer := csv.NewWriter(w)
+	writer.Comma = comma
+	if err = writer.Write(spreadsheetHeader); err != nil {
+		return Error(err)
+	}
+
+	// The partitions hold the code files in grid order.
+	for _, partition := range analysis.Partitions {
+		for _, group := range partition.Groups {
+			for _, file := range group.Files {
+				codeFile := analysis.CodeFiles[file.Name]
+				row := []string{
+					nodePackage(codeFile.Name, granularity),
+					codeFile.Name,
+					strconv.Itoa(codeFile.VisibilityFanIn),
+					strconv.Itoa(codeFile.VisibilityFanOut),
+					partition.Name,
+					strconv.Itoa(group.FileCount),
+					strconv.Itoa(len(codeFile.DependedOnBy)),
+					strconv.Itoa(len(codeFile.DependsOn)),
+					strconv.Itoa(codeFile.Lines),
+				}
+				if err = writer.Write(row); err != nil {
+					return Error(err)
+				}
+			}
+		}
+	}
+
+	writer.Flush()
+	if err = writer.Error(); err != nil {
+		return Error(err)
+	}
+
+	return nil
+}
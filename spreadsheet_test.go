@@ -0,0 +1,42 @@
+package technical_debt
+
+import (
+	"bytes"
+
+	. "gopkg.in/check.v1" // https://labix.org/gocheck
+)
+
+// Create a suite.
+type SpreadsheetSuite struct{}
+
+var _ = Suite(&SpreadsheetSuite{})
+
+// Add the tests.
+
+func (s *SpreadsheetSuite) Test_WriteSpreadsheet(c *C) {
+	codeFiles := testCodeFiles(map[string][]string{
+		"r/a/one.go":   {"r/a/two.go"},
+		"r/a/two.go":   {"r/a/one.go", "r/b/three.go"},
+		"r/b/three.go": {},
+	})
+	for name, lines := range map[string]int{"r/a/one.go": 10, "r/a/two.go": 20, "r/b/three.go": 5} {
+		codeFile := codeFiles[name]
+		codeFile.Lines = lines
+		codeFiles[name] = codeFile
+	}
+	analysis, err := Analyze(codeFiles, VIEW_CORE_PERIPHERY)
+	c.Assert(err, IsNil)
+
+	var buffer bytes.Buffer
+	c.Assert(WriteSpreadsheet(&buffer, analysis, GRANULARITY_FILE, ','), IsNil)
+	c.Check(buffer.String(), Equals, ""+
+		"package,file,visibility fan in,visibility fan out,partition,cyclic group size,direct fan in,direct fan out,lines of code\n"+
+		"r/b,r/b/three.go,3,1,shared,1,1,0,5\n"+
+		"r/a,r/a/one.go,2,3,core,2,1,1,10\n"+
+		"r/a,r/a/two.go,2,3,core,2,1,2,20\n")
+
+	// The same with tabs.
+	buffer.Reset()
+	c.Assert(WriteSpreadsheet(&buffer, analysis, GRANULARITY_FILE, '\t'), IsNil)
+	c.Check(buffer.String()[:24], Equals, "package\tfile\tvisibility ")
+}
@@ -0,0 +1,135 @@
+package technical_debt
+
+import (
+	"sort"
+	"strings"
+)
+
+const (
+	TESTS_EXCLUDE = "exclude" // Test files are not analyzed.
+	TESTS_INCLUDE = "include" // Test files are analyzed as though they were production code.
+	TESTS_OVERLAY = "overlay" // Test dependencies are analyzed apart from production dependencies.
+)
+
+// TestMode gets how test files are analyzed, where the deprecated IncludeTests means include.
+func (c Config) TestMode() string {
+	if c.Tests != "" {
+		return c.Tests
+	}
+	if c.IncludeTests {
+		return TESTS_INCLUDE
+	}
+	return TESTS_EXCLUDE
+}
+
+// analyzeTests is true if test files are parsed at all.
+func (c Config) analyzeTests() bool {
+	return c.TestMode() == TESTS_INCLUDE || c.TestMode() == TESTS_OVERLAY
+}
+
+// isTestFile is true for the files only built by go test.
+func isTestFile(filename string) bool {
+	return strings.HasSuffix(filename, "_test.go")
+}
+
+// WithTests gets a copy of the code files where test dependencies are ordinary dependencies.
+func WithTests(codeFiles map[string]CodeFile) (withTests map[string]CodeFile) {
+
+	withTests = map[string]CodeFile{}
+	for name, codeFile := range codeFiles {
+		dependsOn := map[string]bool{}
+		for dependsOnName := range codeFile.DependsOn {
+			dependsOn[dependsOnName] = true
+		}
+		for dependsOnName := range codeFile.TestDependsOn {
+			dependsOn[dependsOnName] = true
+		}
+		weights := map[string]EdgeWeight{}
+		for dependsOnName, weight := range codeFile.Weights {
+			weights[dependsOnName] = weight
+		}
+		withTests[name] = CodeFile{
+			Name:          name,
+			Test:          codeFile.Test,
+			Generated:     codeFile.Generated,
+			Lines:         codeFile.Lines,
+			DependsOn:     dependsOn,
+			TestDependsOn: map[string]bool{},
+			Weights:       weights,
+		}
+	}
+
+	return withTests
+}
+
+// WithoutTests gets a copy of the code files with only production code and its dependencies.
+func WithoutTests(codeFiles map[string]CodeFile) (withoutTests map[string]CodeFile) {
+
+	withoutTests = map[string]CodeFile{}
+	for name, codeFile := range codeFiles {
+		if codeFile.Test {
+			continue
+		}
+		dependsOn := map[string]bool{}
+		weights := map[string]EdgeWeight{}
+		for dependsOnName := range codeFile.DependsOn {
+			// Production code cannot truly depend on tests, but a name match might say it does.
+			if !codeFiles[dependsOnName].Test {
+				dependsOn[dependsOnName] = true
+				weights[dependsOnName] = codeFile.Weights[dependsOnName]
+			}
+		}
+		withoutTests[name] = CodeFile{
+			Name:          name,
+			Generated:     codeFile.Generated,
+			Lines:         codeFile.Lines,
+			DependsOn:     dependsOn,
+			TestDependsOn: map[string]bool{},
+			Weights:       weights,
+		}
+	}
+
+	return withoutTests
+}
+
+// OverlayTests keeps the deep dependencies of the production analysis with the analysis including tests,
+// so those only from tests can be told apart. The code files are updated in place.
+func OverlayTests(analysis *Analysis, production Analysis) {
+
+	analysis.Production = &production.Closure
+
+	// Production code reaches the same code files with or without tests, so the rest of the code files
+	// reaching a code file only do with tests. Every file depends on itself, which says nothing about who
+	// tests it.
+	for name, codeFile := range analysis.CodeFiles {
+		productionFanIn := 1
+		if _, ok := production.CodeFiles[name]; ok {
+			productionFanIn = production.Closure.VisibilityFanIn(name)
+		}
+		codeFile.TestFanIn = analysis.Closure.VisibilityFanIn(name) - productionFanIn
+		analysis.CodeFiles[name] = codeFile
+	}
+}
+
+// TestHubs finds the production code files that tests depend on the most, up to a count.
+func TestHubs(codeFiles map[string]CodeFile, count int) (hubs []CodeFile) {
+
+	for _, codeFile := range codeFiles {
+		if !codeFile.Test && codeFile.TestFanIn > 0 {
+			hubs = append(hubs, codeFile)
+		}
+	}
+
+	// Most depended on first, by name when tied so the results are stable.
+	sort.Slice(hubs, func(i, j int) bool {
+		if hubs[i].TestFanIn != hubs[j].TestFanIn {
+			return hubs[i].TestFanIn > hubs[j].TestFanIn
+		}
+		return hubs[i].Name < hubs[j].Name
+	})
+	if len(hubs) > count {
+		hubs = hubs[:count]
+	}
+
+	return hubs
+}
@@ -0,0 +1,114 @@
+package technical_debt
+
+import (
+	. "gopkg.in/check.v1" // https://labix.org/gocheck
+)
+
+// Create a suite.
+type TestOverlaySuite struct{}
+
+var _ = Suite(&TestOverlaySuite{})
+
+// Add the tests.
+
+func (s *TestOverlaySuite) Test_TestDependencies(c *C) {
+	for _, resolver := range []string{RESOLVER_SYNTAX, RESOLVER_TYPES} {
+		comment := Commentf("Resolver: %v", resolver)
+		codeFiles := fixtureCodeFiles(c, "testdata/xtest", Config{Tests: TESTS_OVERLAY, Resolver: resolver})
+
+		// Test files are test nodes and their dependencies are test dependencies.
+		c.Check(codeFiles["example.com/xtest/lib/lib.go"].Test, Equals, false, comment)
+		c.Check(codeFiles["example.com/xtest/lib/helper_test.go"].Test, Equals, true, comment)
+		c.Check(dependsOnNames(codeFiles["example.com/xtest/lib/helper_test.go"]), IsNil, comment)
+		c.Check(codeFiles["example.com/xtest/lib/helper_test.go"].TestDependsOn, DeepEquals, map[string]bool{
+			"example.com/xtest/lib/lib.go": true,
+		}, comment)
+
+		// Without tests only the production code is left.
+		withoutTests := WithoutTests(codeFiles)
+		c.Check(len(withoutTests), Equals, 1, comment)
+		c.Check(len(WithTests(codeFiles)), Equals, 4, comment)
+
+		// At package granularity the package under test holds both production code and its tests.
+		packages := CollapseToPackages(codeFiles)
+		c.Check(packages["example.com/xtest/lib"].Test, Equals, false, comment)
+		c.Check(packages["example.com/xtest/lib_test"].Test, Equals, true, comment)
+		c.Check(packages["example.com/xtest/lib_test"].TestDependsOn, DeepEquals, map[string]bool{
+			"example.com/xtest/lib": true,
+		}, comment)
+	}
+}
+
+func (s *TestOverlaySuite) Test_OverlayTests(c *C) {
+	codeFiles := testCodeFiles(map[string][]string{
+		"a.go":      {"b.go"},
+		"b.go":      {},
+		"c.go":      {},
+		"a_test.go": {"a.go"},
+		"c_test.go": {"c.go"},
+	})
+	for _, name := range []string{"a_test.go", "c_test.go"} {
+		codeFile := codeFiles[name]
+		codeFile.Test = true
+		codeFile.TestDependsOn, codeFile.DependsOn = codeFile.DependsOn, map[string]bool{}
+		codeFiles[name] = codeFile
+	}
+	// A production file can also depend on another production file only in tests.
+	codeFile := codeFiles["c.go"]
+	codeFile.TestDependsOn = map[string]bool{"b.go": true}
+	codeFiles["c.go"] = codeFile
+
+	production, err := Analyze(WithoutTests(codeFiles), VIEW_MEDIAN)
+	c.Assert(err, IsNil)
+	analysis, err := Analyze(WithTests(codeFiles), VIEW_MEDIAN)
+	c.Assert(err, IsNil)
+	OverlayTests(&analysis, production)
+
+	c.Check(production.FileCount, Equals, 3)
+	c.Check(analysis.FileCount, Equals, 5)
+	c.Check(production.PropogationCost, Equals, 4.0/9.0)
+	c.Check(analysis.PropogationCost, Equals, 11.0/25.0)
+
+	// Production dependencies are not marked, the deep test dependencies are.
+	tests := []struct {
+		from, to       string
+		testDependency bool
+	}{
+		{from: "a.go", to: "b.go", testDependency: false},
+		{from: "c.go", to: "b.go", testDependency: true},
+		{from: "c.go", to: "a.go", testDependency: false},
+		{from: "a_test.go", to: "a_test.go", testDependency: true},
+		{from: "a_test.go", to: "b.go", testDependency: true},
+		{from: "a_test.go", to: "c.go", testDependency: false},
+	}
+	for i, test := range tests {
+		comment := Commentf("Case %v: %v", i, test)
+		c.Check(analysis.IsTestDependency(test.from, test.to), Equals, test.testDependency, comment)
+	}
+	c.Check(production.IsTestDependency("c.go", "b.go"), Equals, false)
+
+	// b.go is reached by both tests and by c.go once tests are included.
+	c.Check(analysis.CodeFiles["b.go"].TestFanIn, Equals, 3)
+	var hubs []string
+	for _, hub := range TestHubs(analysis.CodeFiles, 2) {
+		hubs = append(hubs, hub.Name)
+	}
+	c.Check(hubs, DeepEquals, []string{"b.go", "a.go"})
+}
+
+func (s *TestOverlaySuite) Test_TestMode(c *C) {
+	tests := []struct {
+		config   Config
+		testMode string
+	}{
+		{config: Config{}, testMode: TESTS_EXCLUDE},
+		{config: Config{IncludeTests: true}, testMode: TESTS_INCLUDE},
+		{config: Config{IncludeTests: true, Tests: TESTS_OVERLAY}, testMode: TESTS_OVERLAY},
+		{config: Config{Tests: TESTS_INCLUDE}, testMode: TESTS_INCLUDE},
+	}
+	for i, test := range tests {
+		comment := Commentf("Case %v: %v", i, test)
+		c.Check(test.config.TestMode(), Equals, test.testMode, comment)
+		c.Check(test.config.analyzeTests(), Equals, test.testMode != TESTS_EXCLUDE, comment)
+	}
+}
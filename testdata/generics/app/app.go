@@ -0,0 +1,14 @@
+package app
+
+import (
+	"example.com/generics/collections"
+)
+
+// Run uses generic types and functions, both inferred and instantiated explicitly.
+func Run() (pair collections.Pair[string, int]) {
+	var m *collections.Map[string, int] = collections.NewMap[string, int]()
+	m.Set("a", 1)
+	evens := collections.Filter[int]([]int{1, 2}, func(i int) bool { return i%2 == 0 })
+	pair.Value = collections.Sum(evens...)
+	return pair
+}
@@ -0,0 +1,11 @@
+package app
+
+import (
+	"example.com/generics/collections"
+)
+
+// Chain selects a generic function on the line after its package name.
+func Chain() []string {
+	return collections.
+		Filter[string]([]string{"a"}, func(string) bool { return true })
+}
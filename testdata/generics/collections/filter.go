@@ -0,0 +1,11 @@
+package collections
+
+// Filter keeps some values.
+func Filter[T any](values []T, keep func(T) bool) (kept []T) {
+	for _, value := range values {
+		if keep(value) {
+			kept = append(kept, value)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,4 @@
+package collections
+
+// K has the same name as a type parameter.
+type K = string
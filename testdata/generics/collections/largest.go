@@ -0,0 +1,9 @@
+package collections
+
+// Largest is constrained by a constraint in another file.
+func Largest[T ordered](a, b T) T {
+	if a > b {
+		return a
+	}
+	return b
+}
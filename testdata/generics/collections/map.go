@@ -0,0 +1,11 @@
+package collections
+
+// Map is a generic map.
+type Map[K comparable, V any] struct {
+	items map[K]V
+}
+
+// NewMap creates a map.
+func NewMap[K comparable, V any]() *Map[K, V] {
+	return &Map[K, V]{items: map[K]V{}}
+}
@@ -0,0 +1,6 @@
+package collections
+
+// Set sets a value. The type parameters are not the package level K.
+func (m *Map[K, V]) Set(key K, value V) {
+	m.items[key] = value
+}
@@ -0,0 +1,6 @@
+package collections
+
+// ordered can be compared.
+type ordered interface {
+	~int | ~string
+}
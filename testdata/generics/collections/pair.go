@@ -0,0 +1,7 @@
+package collections
+
+// Pair is a generic pair.
+type Pair[K comparable, V any] struct {
+	Key   K
+	Value V
+}
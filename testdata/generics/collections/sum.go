@@ -0,0 +1,13 @@
+package collections
+
+import (
+	"example.com/generics/constraints"
+)
+
+// Sum adds numbers, constrained by another package.
+func Sum[T constraints.Number](values ...T) (sum T) {
+	for _, value := range values {
+		sum += value
+	}
+	return sum
+}
@@ -0,0 +1,6 @@
+package constraints
+
+// Float is any floating point number.
+type Float interface {
+	~float32 | ~float64
+}
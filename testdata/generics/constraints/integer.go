@@ -0,0 +1,6 @@
+package constraints
+
+// Integer is any integer.
+type Integer interface {
+	~int | ~int64
+}
@@ -0,0 +1,6 @@
+package constraints
+
+// Number is any number, from constraints declared in other files.
+type Number interface {
+	Integer | Float
+}
@@ -0,0 +1,9 @@
+package colors
+
+// Red is a color.
+var Red = "red"
+
+// Mix mixes colors.
+func Mix() string {
+	return Red
+}
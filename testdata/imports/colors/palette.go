@@ -0,0 +1,7 @@
+package colors
+
+// Palette holds colors.
+type Palette struct{}
+
+// Blend blends the colors of the palette.
+func (p Palette) Blend() {}
@@ -0,0 +1,8 @@
+package colors
+
+// registered is true once the package is initialized.
+var registered bool
+
+func init() {
+	registered = true
+}
@@ -0,0 +1,10 @@
+package paint
+
+import (
+	c "example.com/imports/colors"
+)
+
+// Alias uses an aliased import.
+func Alias() string {
+	return c.Mix()
+}
@@ -0,0 +1,12 @@
+package paint
+
+import (
+	. "example.com/imports/colors"
+	"example.com/imports/shade/v2"
+)
+
+// Paint uses dot imported names and a package whose name is not the last part of its path.
+func Paint() string {
+	shade.Dark()
+	return Mix() + Red
+}
@@ -0,0 +1,10 @@
+package paint
+
+import (
+	. "example.com/imports/colors"
+)
+
+// Use calls a method of a dot imported type.
+func Use(p Palette) {
+	p.Blend()
+}
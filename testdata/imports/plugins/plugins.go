@@ -0,0 +1,8 @@
+package plugins
+
+import (
+	_ "example.com/imports/colors"
+)
+
+// Plugins only needs the colors initialized.
+func Plugins() {}
@@ -0,0 +1,4 @@
+package shade
+
+// Dark darkens.
+func Dark() {}
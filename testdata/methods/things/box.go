@@ -0,0 +1,11 @@
+package things
+
+// Box has a field named like a method of Crate.
+type Box struct {
+	Size int
+}
+
+// Measure reads the field, not the method.
+func Measure(box Box) int {
+	return box.Size
+}
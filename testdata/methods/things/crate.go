@@ -0,0 +1,9 @@
+package things
+
+// Crate has a method named like a field of Box.
+type Crate struct{}
+
+// Size is a method of Crate.
+func (c Crate) Size() int {
+	return 0
+}
@@ -0,0 +1,8 @@
+package things
+
+import "fmt"
+
+// Describe calls a method on a value of a type outside the project.
+func Describe(s fmt.Stringer) string {
+	return s.String()
+}
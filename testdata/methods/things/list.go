@@ -0,0 +1,6 @@
+package things
+
+// List is generic with its methods in another file.
+type List[T any] struct {
+	values []T
+}
@@ -0,0 +1,6 @@
+package things
+
+// Push has a generic receiver.
+func (l *List[T]) Push(value T) {
+	l.values = append(l.values, value)
+}
@@ -0,0 +1,14 @@
+package things
+
+// Name has a method named like one of an interface outside the project.
+type Name string
+
+// String is a method of Name.
+func (n Name) String() string {
+	return string(n)
+}
+
+// Size is a method of Name named like the promoted method of Pallet.
+func (n Name) Size() int {
+	return len(n)
+}
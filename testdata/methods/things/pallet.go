@@ -0,0 +1,11 @@
+package things
+
+// Pallet has the methods of Crate promoted to it.
+type Pallet struct {
+	Crate
+}
+
+// Weigh calls the promoted method, which only Crate and not Name declares for Pallet.
+func Weigh(pallet Pallet) int {
+	return pallet.Size()
+}
@@ -0,0 +1,4 @@
+package things
+
+// Thing has its methods in another file.
+type Thing struct{}
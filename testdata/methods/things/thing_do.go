@@ -0,0 +1,4 @@
+package things
+
+// Do is only a method, nothing in the package scope.
+func (t *Thing) Do() {}
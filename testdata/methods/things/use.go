@@ -0,0 +1,10 @@
+package things
+
+// Use calls the methods on values.
+func Use() {
+	thing := &Thing{}
+	thing.Do()
+
+	var list List[int]
+	list.Push(1)
+}
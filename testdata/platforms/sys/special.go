@@ -0,0 +1,6 @@
+//go:build special
+
+package sys
+
+// Special is only in special builds.
+func Special() {}
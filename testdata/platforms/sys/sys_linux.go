@@ -0,0 +1,7 @@
+package sys
+
+// Name is the linux name.
+func Name() string { return "linux" }
+
+// Major and Minor are the linux version.
+var Major, Minor = 5, 0
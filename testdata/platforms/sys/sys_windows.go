@@ -0,0 +1,7 @@
+package sys
+
+// Name is the windows name.
+func Name() string { return "windows" }
+
+// Minor and Major are the windows version, declared the other way around.
+var Minor, Major = 0, 10
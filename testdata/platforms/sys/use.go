@@ -0,0 +1,11 @@
+package sys
+
+// Use needs the platform name.
+func Use() string {
+	return Name()
+}
+
+// Version needs only the platform minor version.
+func Version() int {
+	return Minor
+}
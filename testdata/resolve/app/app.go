@@ -0,0 +1,13 @@
+package app
+
+import (
+	"fmt"
+
+	"example.com/resolve/shapes"
+)
+
+// Describe depends on the shape, its promoted method and the embedded base.
+func Describe() string {
+	var s shapes.Shape
+	return fmt.Sprint(s.Area())
+}
@@ -0,0 +1,6 @@
+package shapes
+
+// Base is embedded in every shape.
+type Base struct {
+	Name string
+}
@@ -0,0 +1,6 @@
+package shapes
+
+// Area is promoted to every shape.
+func (b Base) Area() int {
+	return 0
+}
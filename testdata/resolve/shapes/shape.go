@@ -0,0 +1,6 @@
+package shapes
+
+// Shape is something with an area.
+type Shape struct {
+	Base
+}
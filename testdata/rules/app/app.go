@@ -0,0 +1,13 @@
+package app
+
+import (
+	"example.com/rules/mocks"
+	"example.com/rules/pb"
+)
+
+// Run uses generated and mock code.
+func Run() Kind {
+	mocks.Mock()
+	pb.Message()
+	return First
+}
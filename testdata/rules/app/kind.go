@@ -0,0 +1,9 @@
+package app
+
+// Kind is a kind of thing.
+type Kind int
+
+// The kinds.
+const (
+	First Kind = iota
+)
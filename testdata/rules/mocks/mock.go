@@ -0,0 +1,4 @@
+package mocks
+
+// Mock pretends.
+func Mock() {}
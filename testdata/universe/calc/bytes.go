@@ -0,0 +1,7 @@
+package calc
+
+// Bytes only uses predeclared names.
+func Bytes(values []byte) []byte {
+	var r rune = 'a'
+	return append(values, byte(r), byte(len(values)))
+}
@@ -0,0 +1,6 @@
+package calc
+
+// Use calls the min of this package.
+func Use(values []int) int {
+	return min(len(values), cap(values))
+}
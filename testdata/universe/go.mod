@@ -0,0 +1,3 @@
+module example.com/universe
+
+go 1.21
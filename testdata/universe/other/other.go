@@ -0,0 +1,10 @@
+package other
+
+import (
+	. "example.com/universe/calc"
+)
+
+// Other calls the builtin min, which the dot import does not replace.
+func Other() int {
+	return min(Use(nil), 1)
+}
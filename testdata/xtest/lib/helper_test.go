@@ -0,0 +1,6 @@
+package lib
+
+// helper is in the package under test.
+func helper() {
+	Lib()
+}
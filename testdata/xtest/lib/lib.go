@@ -0,0 +1,4 @@
+package lib
+
+// Lib is under test.
+func Lib() {}
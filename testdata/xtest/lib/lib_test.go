@@ -0,0 +1,13 @@
+package lib_test
+
+import (
+	"testing"
+
+	"example.com/xtest/lib"
+)
+
+// TestLib only sees the exported parts of the package.
+func TestLib(t *testing.T) {
+	lib.Lib()
+	check(t)
+}
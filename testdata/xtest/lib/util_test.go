@@ -0,0 +1,13 @@
+package lib_test
+
+import (
+	"testing"
+)
+
+// Lib has the same name as the function under test.
+func Lib() {}
+
+// check is shared by the tests.
+func check(t *testing.T) {
+	Lib()
+}
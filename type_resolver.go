@@ -0,0 +1,222 @@
+package technical_debt
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+const (
+	RESOLVER_SYNTAX = "syntax" // Matches names alone, so over-approximates where only types could tell.
+	RESOLVER_TYPES  = "types"  // Type checks the project, for exact references.
+)
+
+// typeChecker type checks the project packages from their parsed source.
+type typeChecker struct {
+	fset     *token.FileSet
+	sources  map[string][]*ast.File    // The parsed files of each project package by import path.
+	checked  map[string]*types.Package // The packages already type checked.
+	checking map[string]bool           // The packages part way through type checking, to catch cycles.
+	infos    map[string]*types.Info    // What the type checker learned about each package.
+	fallback types.Importer            // For packages outside the project.
+}
+
+// Import implements types.Importer.
+func (t *typeChecker) Import(importPath string) (pkg *types.Package, err error) {
+
+	if pkg, ok := t.checked[importPath]; ok {
+		return pkg, nil
+	}
+
+	// Packages outside the project only need to be known well enough to check our code.
+	files, ok := t.sources[importPath]
+	if !ok {
+		return t.fallback.Import(importPath)
+	}
+
+	if t.checking[importPath] {
+		return nil, Errorf(`import cycle through %s`, importPath)
+	}
+
+	return t.check(importPath, files), nil
+}
+
+// check type checks a single package.
+func (t *typeChecker) check(importPath string, files []*ast.File) (pkg *types.Package) {
+	t.checking[importPath] = true
+	defer delete(t.checking, importPath)
+
+	info := &types.Info{
+		Uses:       map[*ast.Ident]types.Object{},
+		Selections: map[*ast.SelectorExpr]*types.Selection{},
+	}
+	config := types.Config{
+		Importer: t,
+		// Type errors (often from packages outside the project that could not be imported)
+		// do not stop the analysis. Everything that can be resolved still is.
+		Error: func(err error) {},
+	}
+	pkg, _ = config.Check(importPath, t.fset, files, info)
+
+	t.checked[importPath] = pkg
+	t.infos[importPath] = info
+	return pkg
+}
+
+// resolveTypes type checks the folders and replaces the unresolved references of each file
+// with the files declaring every project object the file uses.
+func resolveTypes(fset *token.FileSet, folders []packageFolder, projectPaths []string) (err error) {
+
+	checker := &typeChecker{
+		fset:     fset,
+		sources:  map[string][]*ast.File{},
+		checked:  map[string]*types.Package{},
+		checking: map[string]bool{},
+		infos:    map[string]*types.Info{},
+		fallback: importer.Default(),
+	}
+
+	// Know every project file by its path on disk so declarations can be traced to files.
+	// External test packages already have import paths of their own, that nothing else imports.
+	files := map[string]packageFile{}
+	locations := map[string]declarationLocation{}
+	for _, folder := range folders {
+		for _, file := range folder.files {
+			files[file.path] = file
+			locations[file.path] = declarationLocation{importPath: folder.importPath, file: file.name}
+			checker.sources[folder.importPath] = append(checker.sources[folder.importPath], file.syntax)
+		}
+	}
+
+	// Check in a stable order so repeated runs agree.
+	var sortedPaths []string
+	for checkPath := range checker.sources {
+		sortedPaths = append(sortedPaths, checkPath)
+	}
+	sort.Strings(sortedPaths)
+	for _, checkPath := range sortedPaths {
+		if _, err = checker.Import(checkPath); err != nil {
+			return Error(err)
+		}
+	}
+
+	// Trace every use back to the file declaring it.
+	for i, folder := range folders {
+		info := checker.infos[folder.importPath]
+
+		// Group references by the file they appear in.
+		references := map[string]map[string]fileUnresolved{}
+		addReference := func(at token.Pos, obj types.Object) {
+			if obj == nil || obj.Pkg() == nil || !obj.Pos().IsValid() {
+				// Universe objects like len or error are declared nowhere.
+				return
+			}
+			if !inProject(strings.TrimSuffix(obj.Pkg().Path(), "_test"), projectPaths) {
+				return
+			}
+			declaredIn := fset.Position(obj.Pos()).Filename
+			target, ok := locations[declaredIn]
+			if !ok {
+				return
+			}
+			target.declaration = enclosingDeclaration(files[declaredIn].ranges, obj.Pos())
+			usedIn := fset.Position(at).Filename
+			from := enclosingDeclaration(files[usedIn].ranges, at)
+			if references[usedIn] == nil {
+				references[usedIn] = map[string]fileUnresolved{}
+			}
+			name := objectName(obj, target.declaration)
+			key := from + ":" + target.nodeName(GRANULARITY_DECLARATION) + ":" + name
+			references[usedIn][key] = fileUnresolved{name: name, from: from, target: target, count: references[usedIn][key].count + 1}
+		}
+
+		for ident, obj := range info.Uses {
+			addReference(ident.Pos(), obj)
+		}
+
+		// Promoted fields and methods implicitly pass through each embedded field.
+		for selector, selection := range info.Selections {
+			for _, object := range embeddedObjects(selection) {
+				addReference(selector.Sel.Pos(), object)
+			}
+		}
+
+		for j, file := range folder.files {
+			var keys []string
+			for key := range references[file.path] {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+
+			// Package initialization is not a use of any object, so is kept from the syntax.
+			var packageInits []fileUnresolved
+			for _, unresolved := range file.unresolved {
+				if unresolved.packageInit {
+					packageInits = append(packageInits, unresolved)
+				}
+			}
+			folders[i].files[j].unresolved = packageInits
+			for _, key := range keys {
+				folders[i].files[j].unresolved = append(folders[i].files[j].unresolved, references[file.path][key])
+			}
+		}
+	}
+
+	return nil
+}
+
+// objectName gets the name an object is declared under in its package, as the syntax finds it, so declarations
+// of the name for other platforms can be found. Anything not declared at the top level, like a field, goes by the
+// declaration it is part of.
+func objectName(obj types.Object, declaration string) (name string) {
+	if obj.Parent() != nil && obj.Parent() == obj.Pkg().Scope() {
+		return obj.Name()
+	}
+	if function, ok := obj.(*types.Func); ok {
+		if recv := function.Type().(*types.Signature).Recv(); recv != nil {
+			recvType := recv.Type()
+			if pointer, ok := recvType.(*types.Pointer); ok {
+				recvType = pointer.Elem()
+			}
+			if named, ok := recvType.(*types.Named); ok {
+				return named.Obj().Name() + "." + function.Name()
+			}
+		}
+	}
+	return declaration
+}
+
+// embeddedObjects finds the embedded fields, and their types, a selection passes through on
+// the way to what it selects.
+func embeddedObjects(selection *types.Selection) (objects []types.Object) {
+
+	typ := selection.Recv()
+	indices := selection.Index()
+	for _, index := range indices[:len(indices)-1] {
+		if pointer, ok := typ.Underlying().(*types.Pointer); ok {
+			typ = pointer.Elem()
+		}
+		structType, ok := typ.Underlying().(*types.Struct)
+		if !ok {
+			// Embedded interfaces have no fields to pass through.
+			break
+		}
+		field := structType.Field(index)
+		objects = append(objects, field)
+		typ = field.Type()
+
+		// The field is declared in one file, the type it embeds may be in another.
+		embedded := typ
+		if pointer, ok := embedded.(*types.Pointer); ok {
+			embedded = pointer.Elem()
+		}
+		if named, ok := embedded.(*types.Named); ok {
+			objects = append(objects, named.Obj())
+		}
+	}
+
+	return objects
+}